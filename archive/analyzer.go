@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// stopWords are frequent Indonesian function words left out of the index and
+// of queries alike, so phrase adjacency stays consistent between the two.
+var stopWords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true,
+	"dengan": true, "ini": true, "itu": true, "pada": true, "dalam": true, "adalah": true,
+	"akan": true, "tidak": true, "juga": true, "atau": true, "ada": true, "oleh": true,
+	"sebagai": true, "karena": true, "bisa": true, "telah": true, "sudah": true,
+	"saat": true, "hingga": true, "kata": true, "para": true, "the": true, "of": true,
+}
+
+// analyze turns text into the stemmed tokens stored in and matched against
+// the full-text index.
+func analyze(text string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, stem(word))
+	}
+	return tokens
+}
+
+// htmlText returns the visible text of cleaned article HTML.
+func htmlText(content string) string {
+	if content == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	return doc.Text()
+}
+
+// minStem is the shortest stem affix stripping may leave behind.
+const minStem = 3
+
+// stem reduces an Indonesian word to its root by stripping inflectional
+// particles and possessives, then derivational suffixes and prefixes, in the
+// spirit of Nazief-Adriani but without a root dictionary. Without one some
+// words are over- or under-stemmed; what matters for search is that the same
+// word always gets the same stem.
+func stem(word string) string {
+	if utf8.RuneCountInString(word) <= minStem+1 || !isAlpha(word) {
+		return word
+	}
+	// Particles need a longer remainder so roots like "masalah" survive.
+	word = stripSuffix(word, minStem+1, "lah", "kah", "tah", "pun")
+	word = stripSuffix(word, minStem, "nya", "ku", "mu")
+
+	stemmed := stripPrefixes(word)
+	if stemmed != word {
+		// Derivational suffixes are only stripped from prefixed words:
+		// "bukan" and "kali" are roots, "dibukakan" and "mengobati" are not.
+		// Roots ending in "ai" (pakai, sampai, selesai) keep their "i".
+		if strings.HasSuffix(stemmed, "ai") {
+			return stripSuffix(stemmed, minStem, "kan", "an")
+		}
+		return stripSuffix(stemmed, minStem, "kan", "an", "i")
+	}
+	return stripSuffix(word, minStem, "kan", "an")
+}
+
+// stripSuffix removes the first matching suffix if more than keep bytes remain.
+func stripSuffix(word string, keep int, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) > keep {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// prefixRules are tried in order; replace is prepended to what's left, which
+// restores the initial consonant nasal prefixes swallow (menulis -> tulis).
+var prefixRules = []struct {
+	prefix  string
+	next    string // the rule only applies if the remainder starts with one of these
+	replace string
+}{
+	{"meny", "aiueo", "s"},
+	{"peny", "aiueo", "s"},
+	{"meng", "aiueogh", ""},
+	{"peng", "aiueogh", ""},
+	{"mem", "bfvp", ""},
+	{"pem", "bfvp", ""},
+	{"mem", "aiueo", "p"},
+	{"pem", "aiueo", "p"},
+	{"men", "cdjzt", ""},
+	{"pen", "cdjzt", ""},
+	{"men", "aiueo", "t"},
+	{"pen", "aiueo", "t"},
+	{"me", "lrwymn", ""},
+	{"ber", "", ""},
+	{"ter", "", ""},
+	{"per", "", ""},
+	{"pe", "lrwymn", ""},
+	{"di", "", ""},
+	{"ke", "", ""},
+	{"se", "", ""},
+}
+
+// stripPrefixes removes up to two derivational prefixes (diperbaiki -> baik).
+func stripPrefixes(word string) string {
+	for round := 0; round < 2; round++ {
+		stripped := stripPrefix(word)
+		if stripped == word {
+			break
+		}
+		word = stripped
+	}
+	return word
+}
+
+func stripPrefix(word string) string {
+	for _, rule := range prefixRules {
+		if !strings.HasPrefix(word, rule.prefix) {
+			continue
+		}
+		rest := word[len(rule.prefix):]
+		if rest == "" || (rule.next != "" && !strings.ContainsRune(rule.next, rune(rest[0]))) {
+			continue
+		}
+		if len(rule.replace)+len(rest) <= minStem {
+			return word
+		}
+		return rule.replace + rest
+	}
+	return word
+}
+
+func isAlpha(word string) bool {
+	for _, r := range word {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"menulis":      "tulis",
+		"diperbaiki":   "baik",
+		"kebakaran":    "bakar",
+		"kenaikan":     "naik",
+		"pertandingan": "tanding",
+		"menyapu":      "sapu",
+		"memakai":      "pakai",
+		"bukunya":      "buku",
+		"masalah":      "masalah",
+		"berita":       "berita",
+		"bukan":        "bukan",
+		"kali":         "kali",
+		"jakarta":      "jakarta",
+		"2024":         "2024",
+	}
+	for word, want := range cases {
+		assert.Equal(t, want, stem(word), word)
+	}
+}
+
+func TestAnalyzeDropsStopWords(t *testing.T) {
+	assert.Equal(t, []string{"harga", "beras", "naik"}, analyze("Harga beras yang naik!"))
+}
+
+func TestMatchExpression(t *testing.T) {
+	assert.Equal(t, `"harga beras" naik`, matchExpression(`"Harga yang beras" kenaikan`))
+	assert.Equal(t, `banjir OR bakar`, matchExpression(`banjir OR kebakaran`))
+	assert.Equal(t, `banjir`, matchExpression(`OR banjir OR`))
+	assert.Equal(t, ``, matchExpression(`yang dan`))
+}
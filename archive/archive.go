@@ -0,0 +1,466 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// migration is one schema change. backfill, when set, runs in the same
+// transaction after sql, for data that can't be migrated in SQL alone.
+type migration struct {
+	sql      string
+	backfill func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations are applied in order; the index of each entry plus one is its
+// schema version. Never edit an entry once released, append a new one.
+var migrations = []migration{
+	{sql: `CREATE TABLE articles (
+		id             INTEGER PRIMARY KEY,
+		source         TEXT    NOT NULL,
+		url_key        TEXT    NOT NULL,
+		source_url     TEXT    NOT NULL DEFAULT '',
+		detail_url     TEXT    NOT NULL DEFAULT '',
+		title          TEXT    NOT NULL DEFAULT '',
+		short_desc     TEXT    NOT NULL DEFAULT '',
+		author         TEXT    NOT NULL DEFAULT '',
+		date           TEXT    NOT NULL DEFAULT '',
+		published_at   TEXT,
+		published_unix INTEGER,
+		img_url        TEXT    NOT NULL DEFAULT '',
+		content        TEXT    NOT NULL DEFAULT '',
+		fetched_at     INTEGER NOT NULL,
+		updated_at     INTEGER NOT NULL,
+		UNIQUE (source, url_key)
+	);
+	CREATE INDEX articles_source_published ON articles (source, published_unix);
+	CREATE INDEX articles_published ON articles (published_unix);`},
+	// articles_fts holds the analyzed (stemmed) title and body of each
+	// article, keyed by docid = articles.id.
+	{sql: `CREATE VIRTUAL TABLE articles_fts USING fts4(title, body)`, backfill: reindexAll},
+	// url_key becomes the article ID from package canonical. Keys are first
+	// made unique by row, so rekeying can't collide with a row not yet done.
+	{sql: `UPDATE articles SET url_key = '#' || id`, backfill: rekeyArticles},
+}
+
+// rekeyArticles sets url_key to each article's canonical ID. Rows that turn
+// out to be variants of the same article are merged into the one with
+// content, or else the most recently updated.
+func rekeyArticles(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, source, source_url FROM articles
+		ORDER BY content != '' DESC, updated_at DESC, id DESC`)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id                int64
+		source, sourceURL string
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.source, &r.sourceURL); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	kept := map[string]bool{}
+	for _, r := range all {
+		key := canonical.ID(r.sourceURL)
+		if kept[r.source+" "+key] {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM articles WHERE id = ?`, r.id); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM articles_fts WHERE docid = ?`, r.id); err != nil {
+				return err
+			}
+			continue
+		}
+		kept[r.source+" "+key] = true
+		if _, err := tx.ExecContext(ctx, `UPDATE articles SET url_key = ? WHERE id = ?`, key, r.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// driverName is go-sqlite3 with Gober's SQL functions registered (see
+// search.go).
+const driverName = "sqlite3_gober"
+
+// Archive is a persistent store of every article Gober has scraped, kept in
+// an embedded SQLite database.
+type Archive struct {
+	db *sql.DB
+
+	// saves feeds the writer goroutine started by Open; see SaveAsync.
+	mu      sync.Mutex
+	saves   chan saveJob
+	closed  bool
+	written chan struct{}
+}
+
+// saveJob is one SaveAsync call, or a Flush marker when flushed is set.
+type saveJob struct {
+	source   string
+	articles []models.Article
+	flushed  chan struct{}
+}
+
+// saveQueueSize bounds the saves waiting for the writer. Beyond it saves are
+// dropped: the archive is best effort and must not hold up requests.
+const saveQueueSize = 256
+
+// Open opens (creating if needed) the archive database at path and brings its
+// schema up to date. Use ":memory:" for a throwaway archive.
+func Open(path string) (*Archive, error) {
+	db, err := sql.Open(driverName, "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids "database is locked"
+	// and keeps :memory: databases from being per-connection.
+	db.SetMaxOpenConns(1)
+
+	a := &Archive{db: db, saves: make(chan saveJob, saveQueueSize), written: make(chan struct{})}
+	if err := a.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate archive: %w", err)
+	}
+	go a.writeSaves()
+	return a, nil
+}
+
+// Close writes the saves still queued, then closes the database.
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.saves)
+	}
+	a.mu.Unlock()
+	<-a.written
+	return a.db.Close()
+}
+
+// SaveAsync queues articles to be saved off the caller's path. Errors are
+// logged, and saves are dropped while the queue is full.
+func (a *Archive) SaveAsync(source string, articles ...models.Article) {
+	if len(articles) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.saves <- saveJob{source: source, articles: articles}:
+	default:
+		log.Printf("archive queue is full, dropping %d %s articles", len(articles), source)
+	}
+}
+
+// Flush waits until every save queued before it has been written.
+func (a *Archive) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	select {
+	case a.saves <- saveJob{flushed: flushed}:
+		a.mu.Unlock()
+	case <-ctx.Done():
+		a.mu.Unlock()
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *Archive) writeSaves() {
+	defer close(a.written)
+	for job := range a.saves {
+		if job.flushed != nil {
+			close(job.flushed)
+			continue
+		}
+		if err := a.Save(context.Background(), job.source, job.articles...); err != nil {
+			log.Printf("archive %s articles failed: %v", job.source, err)
+		}
+	}
+}
+
+// SchemaVersion returns the number of migrations applied.
+func (a *Archive) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := a.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func (a *Archive) migrate(ctx context.Context) error {
+	if _, err := a.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	current, err := a.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	for i := current; i < len(migrations); i++ {
+		tx, err := a.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i].sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if migrations[i].backfill != nil {
+			if err := migrations[i].backfill(ctx, tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d backfill: %w", i+1, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save stores articles for source. List entries and details of the same
+// article are merged: empty fields never overwrite what is already archived,
+// so a later list fetch keeps the content saved from an earlier detail fetch.
+func (a *Archive) Save(ctx context.Context, source string, articles ...models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO articles (
+			source, url_key, source_url, detail_url, title, short_desc, author, date,
+			published_at, published_unix, img_url, content, fetched_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, url_key) DO UPDATE SET
+			source_url     = COALESCE(NULLIF(excluded.source_url, ''), source_url),
+			detail_url     = COALESCE(NULLIF(excluded.detail_url, ''), detail_url),
+			title          = COALESCE(NULLIF(excluded.title, ''), title),
+			short_desc     = COALESCE(NULLIF(excluded.short_desc, ''), short_desc),
+			author         = COALESCE(NULLIF(excluded.author, ''), author),
+			date           = COALESCE(NULLIF(excluded.date, ''), date),
+			published_at   = COALESCE(excluded.published_at, published_at),
+			published_unix = COALESCE(excluded.published_unix, published_unix),
+			img_url        = COALESCE(NULLIF(excluded.img_url, ''), img_url),
+			content        = COALESCE(NULLIF(excluded.content, ''), content),
+			updated_at     = excluded.updated_at
+		RETURNING id, title, short_desc, content`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	for _, article := range articles {
+		detailURL := detailURLOf(article)
+		sourceURL := article.SourceUrl
+		if sourceURL == "" {
+			sourceURL = detailURL
+		}
+		if sourceURL == "" {
+			continue
+		}
+
+		var publishedAt, publishedUnix any
+		if article.PublishedAt != nil {
+			publishedAt = article.PublishedAt.Format(time.RFC3339)
+			publishedUnix = article.PublishedAt.Unix()
+		}
+		var id int64
+		var title, shortDesc, content string
+		if err := stmt.QueryRowContext(ctx,
+			source, canonical.ID(sourceURL), sourceURL, detailURL,
+			strings.TrimSpace(article.Title), article.ShortDesc, article.Author, article.Date,
+			publishedAt, publishedUnix, article.ImgUrl, article.Content, now, now,
+		).Scan(&id, &title, &shortDesc, &content); err != nil {
+			return err
+		}
+		if err := index(ctx, tx, id, title, shortDesc, content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Get returns the archived article at rawURL (any of its query string
+// variants) in the same shape a scraper's Detail returns.
+func (a *Archive) Get(ctx context.Context, source, rawURL string) (models.Article, bool, error) {
+	row := a.db.QueryRowContext(ctx, `SELECT `+columns+` FROM articles a WHERE a.source = ? AND a.url_key = ?`,
+		source, canonical.ID(rawURL))
+	stored, err := scanArticle(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Article{}, false, nil
+	}
+	if err != nil {
+		return models.Article{}, false, err
+	}
+
+	article := stored.article
+	article.URL = stored.detailURL
+	if article.URL == "" {
+		article.URL = article.SourceUrl
+	}
+	return article, true, nil
+}
+
+// Filter narrows Query and Search. Zero values match everything.
+type Filter struct {
+	Source string
+	Since  *time.Time
+	Until  *time.Time
+	// Title matches articles whose title contains it, case-insensitively.
+	Title  string
+	Limit  int
+	Offset int
+}
+
+// Query lists archived articles newest first, in the same shape as a
+// scraper's list results (no content, URL pointing at the detail endpoint).
+func (a *Archive) Query(ctx context.Context, filter Filter, links utils.LinkBuilder) ([]models.Article, error) {
+	where, args := filter.where()
+	query := `SELECT ` + listColumns + ` FROM articles a`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.published_unix DESC NULLS LAST, a.updated_at DESC, a.id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanListEntries(rows, links)
+}
+
+// where returns the SQL conditions (on articles aliased a) for f's fields
+// other than Limit and Offset.
+func (f Filter) where() ([]string, []any) {
+	var where []string
+	var args []any
+	if f.Source != "" {
+		where = append(where, "a.source = ?")
+		args = append(args, f.Source)
+	}
+	if f.Since != nil {
+		where = append(where, "a.published_unix >= ?")
+		args = append(args, f.Since.Unix())
+	}
+	if f.Until != nil {
+		where = append(where, "a.published_unix <= ?")
+		args = append(args, f.Until.Unix())
+	}
+	if f.Title != "" {
+		where = append(where, "a.title LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(f.Title)+"%")
+	}
+	return where, args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+const columns = `a.source, a.url_key, a.source_url, a.detail_url, a.title, a.short_desc, a.author, a.date, a.published_at, a.img_url, a.content`
+
+type storedArticle struct {
+	article   models.Article
+	detailURL string
+}
+
+// listEntry returns the article as a list result: no content, URL pointing at
+// the Gober detail endpoint.
+func (s storedArticle) listEntry(links utils.LinkBuilder) models.Article {
+	article := s.article
+	article.Content = ""
+	detailURL := s.detailURL
+	if detailURL == "" {
+		detailURL = article.SourceUrl
+	}
+	article.URL = links.Article(article.Source, detailURL)
+	return article
+}
+
+func scanArticle(row interface{ Scan(...any) error }) (storedArticle, error) {
+	var s storedArticle
+	var publishedAt sql.NullString
+	err := row.Scan(&s.article.Source, &s.article.ID, &s.article.SourceUrl, &s.detailURL, &s.article.Title,
+		&s.article.ShortDesc, &s.article.Author, &s.article.Date, &publishedAt,
+		&s.article.ImgUrl, &s.article.Content)
+	if err != nil {
+		return s, err
+	}
+	s.setPublishedAt(publishedAt)
+	return s, nil
+}
+
+func (s *storedArticle) setPublishedAt(publishedAt sql.NullString) {
+	if publishedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, publishedAt.String); err == nil {
+			s.article.PublishedAt = &t
+		}
+	}
+}
+
+// detailURLOf returns the upstream URL to pass to Detail for article. List
+// entries carry it in the detailUrl param of their Gober link, details in URL.
+func detailURLOf(article models.Article) string {
+	parsed, err := url.Parse(article.URL)
+	if err != nil {
+		return ""
+	}
+	if detailURL := parsed.Query().Get("detailUrl"); detailURL != "" {
+		return detailURL
+	}
+	if parsed.Scheme == "http" || parsed.Scheme == "https" {
+		return article.URL
+	}
+	return ""
+}
@@ -0,0 +1,310 @@
+package archive_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/archive"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func openArchive(t *testing.T) *archive.Archive {
+	a, err := archive.Open(filepath.Join(t.TempDir(), "gober.db"))
+	assert.Nil(t, err)
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+func at(day, hour int) *time.Time {
+	t := time.Date(2024, 12, day, hour, 0, 0, 0, wib)
+	return &t
+}
+
+func TestOpenMigratesOnce(t *testing.T) {
+	//prepare data
+	path := filepath.Join(t.TempDir(), "gober.db")
+	a, err := archive.Open(path)
+	assert.Nil(t, err)
+	assert.Nil(t, a.Save(context.Background(), "detik", models.Article{SourceUrl: "https://news.detik.com/d-1", Title: "Satu"}))
+	assert.Nil(t, a.Close())
+
+	//do test
+	a, err = archive.Open(path)
+	assert.Nil(t, err)
+	defer a.Close()
+
+	//assertions
+	version, err := a.SchemaVersion(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, version)
+	_, found, err := a.Get(context.Background(), "detik", "https://news.detik.com/d-1")
+	assert.Nil(t, err)
+	assert.True(t, found)
+}
+
+func TestMigrationRekeysArticleVariants(t *testing.T) {
+	//prepare data
+	path := filepath.Join(t.TempDir(), "gober.db")
+	a, err := archive.Open(path)
+	assert.Nil(t, err)
+	assert.Nil(t, a.Close())
+	// rows as version 2 keyed them, by host and path
+	db, err := sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+	_, err = db.Exec(`INSERT INTO articles (source, url_key, source_url, title, content, fetched_at, updated_at) VALUES
+		('detik', 'news.detik.com/berita/d-7/banjir', 'https://news.detik.com/berita/d-7/banjir?single=1', 'Banjir', '<p>isi</p>', 1, 1),
+		('detik', 'm.detik.com/news/berita/d-7/banjir', 'https://m.detik.com/news/berita/d-7/banjir', 'Banjir', '', 1, 2);
+		DELETE FROM schema_migrations WHERE version = 3;`)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Close())
+
+	//do test
+	a, err = archive.Open(path)
+	assert.Nil(t, err)
+	defer a.Close()
+
+	//assertions
+	article, found, err := a.Get(context.Background(), "detik", "https://news.detik.com/amp/berita/d-7/banjir")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "d-7", article.ID)
+	assert.Equal(t, "<p>isi</p>", article.Content)
+	db, err = sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+	defer db.Close()
+	var rows int
+	assert.Nil(t, db.QueryRow(`SELECT COUNT(*) FROM articles`).Scan(&rows))
+	assert.Equal(t, 1, rows)
+}
+
+func TestSaveMergesListAndDetail(t *testing.T) {
+	//prepare data
+	a := openArchive(t)
+	ctx := context.Background()
+	listEntry := models.Article{
+		URL:         "http://gober.test/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fd-1%3Fsingle%3D1",
+		SourceUrl:   "https://news.detik.com/d-1?single=1",
+		Title:       "Banjir di Jakarta",
+		ImgUrl:      "https://akcdn.detik.net.id/foto.jpg",
+		PublishedAt: at(2, 10),
+	}
+	detail := models.Article{
+		URL:     "https://news.detik.com/d-1?single=1",
+		Title:   "Banjir di Jakarta",
+		Author:  "Tim detikcom",
+		Content: "<p>Hujan deras</p>",
+	}
+
+	//do test
+	assert.Nil(t, a.Save(ctx, "detik", listEntry))
+	assert.Nil(t, a.Save(ctx, "detik", detail))
+	// a later list fetch must not wipe the content
+	assert.Nil(t, a.Save(ctx, "detik", listEntry))
+	article, found, err := a.Get(ctx, "detik", "https://news.detik.com/d-1")
+
+	//assertions
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "https://news.detik.com/d-1?single=1", article.URL)
+	assert.Equal(t, "Tim detikcom", article.Author)
+	assert.Equal(t, "<p>Hujan deras</p>", article.Content)
+	assert.Equal(t, "https://akcdn.detik.net.id/foto.jpg", article.ImgUrl)
+	assert.True(t, listEntry.PublishedAt.Equal(*article.PublishedAt))
+	assert.Equal(t, "detik", article.Source)
+}
+
+func TestQuery(t *testing.T) {
+	//prepare data
+	a := openArchive(t)
+	ctx := context.Background()
+	assert.Nil(t, a.Save(ctx, "detik",
+		models.Article{SourceUrl: "https://news.detik.com/d-1", Title: "Banjir di Jakarta", PublishedAt: at(1, 10)},
+		models.Article{SourceUrl: "https://news.detik.com/d-2", Title: "Harga beras naik", PublishedAt: at(3, 10)},
+		models.Article{SourceUrl: "https://news.detik.com/d-3", Title: "Banjir 100% surut"},
+	))
+	assert.Nil(t, a.Save(ctx, "kompas",
+		models.Article{SourceUrl: "https://www.kompas.com/read/1", Title: "Banjir Bekasi", PublishedAt: at(2, 10), Content: "<p>isi</p>"},
+	))
+	links := utils.LinkBuilder{}
+
+	//do test
+	all, err := a.Query(ctx, archive.Filter{}, links)
+	assert.Nil(t, err)
+	bySource, err := a.Query(ctx, archive.Filter{Source: "detik"}, links)
+	assert.Nil(t, err)
+	byTitle, err := a.Query(ctx, archive.Filter{Title: "banjir"}, links)
+	assert.Nil(t, err)
+	byDate, err := a.Query(ctx, archive.Filter{Since: at(2, 0), Until: at(2, 23)}, links)
+	assert.Nil(t, err)
+	literal, err := a.Query(ctx, archive.Filter{Title: "100%"}, links)
+	assert.Nil(t, err)
+	paged, err := a.Query(ctx, archive.Filter{Limit: 2, Offset: 1}, links)
+	assert.Nil(t, err)
+
+	//assertions
+	assert.Equal(t, []string{"Harga beras naik", "Banjir Bekasi", "Banjir di Jakarta", "Banjir 100% surut"}, titlesOf(all))
+	assert.Len(t, bySource, 3)
+	assert.Equal(t, []string{"Banjir Bekasi", "Banjir di Jakarta", "Banjir 100% surut"}, titlesOf(byTitle))
+	assert.Equal(t, []string{"Banjir Bekasi"}, titlesOf(byDate))
+	assert.Equal(t, []string{"Banjir 100% surut"}, titlesOf(literal))
+	assert.Equal(t, []string{"Banjir Bekasi", "Banjir di Jakarta"}, titlesOf(paged))
+	assert.Equal(t, "/article?source=kompas&detailUrl=https%3A%2F%2Fwww.kompas.com%2Fread%2F1", all[1].URL)
+	assert.Empty(t, all[1].Content)
+}
+
+func TestSearch(t *testing.T) {
+	//prepare data
+	a := openArchive(t)
+	ctx := context.Background()
+	assert.Nil(t, a.Save(ctx, "detik",
+		models.Article{SourceUrl: "https://news.detik.com/d-1", Title: "Warga Bekasi kebanjiran", PublishedAt: at(1, 10)},
+		models.Article{SourceUrl: "https://news.detik.com/d-2", Title: "Harga beras naik", PublishedAt: at(3, 10),
+			Content: "<p>Pedagang menyebut <b>banjir</b> membuat pasokan beras turun.</p>"},
+		models.Article{SourceUrl: "https://news.detik.com/d-3", Title: "Beras impor tiba", PublishedAt: at(2, 10),
+			Content: "<p>Harga di pasar belum turun.</p>"},
+	))
+	assert.Nil(t, a.Save(ctx, "kompas",
+		models.Article{SourceUrl: "https://www.kompas.com/read/1", Title: "Banjir rendam Jakarta", PublishedAt: at(2, 12)},
+	))
+	links := utils.LinkBuilder{}
+
+	//do test
+	stemmed, err := a.Search(ctx, "banjir", archive.Filter{}, links)
+	assert.Nil(t, err)
+	phrase, err := a.Search(ctx, `"harga beras"`, archive.Filter{}, links)
+	assert.Nil(t, err)
+	words, err := a.Search(ctx, `harga beras`, archive.Filter{}, links)
+	assert.Nil(t, err)
+	either, err := a.Search(ctx, `impor OR rendam`, archive.Filter{}, links)
+	assert.Nil(t, err)
+	filtered, err := a.Search(ctx, "banjir", archive.Filter{Source: "detik", Since: at(1, 12)}, links)
+	assert.Nil(t, err)
+	paged, err := a.Search(ctx, "banjir", archive.Filter{Limit: 1, Offset: 1}, links)
+	assert.Nil(t, err)
+	none, err := a.Search(ctx, "yang", archive.Filter{}, links)
+	assert.Nil(t, err)
+
+	//assertions
+	// title matches outrank body matches
+	assert.Len(t, stemmed, 3)
+	assert.Equal(t, "Harga beras naik", stemmed[2].Title)
+	assert.Equal(t, []string{"Harga beras naik"}, titlesOf(phrase))
+	assert.ElementsMatch(t, []string{"Harga beras naik", "Beras impor tiba"}, titlesOf(words))
+	assert.ElementsMatch(t, []string{"Beras impor tiba", "Banjir rendam Jakarta"}, titlesOf(either))
+	assert.Equal(t, []string{"Harga beras naik"}, titlesOf(filtered))
+	assert.Equal(t, []string{stemmed[1].Title}, titlesOf(paged))
+	assert.Empty(t, none)
+	assert.Empty(t, stemmed[2].Content)
+	assert.Equal(t, "/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fd-2", stemmed[2].URL)
+}
+
+// failingScraper caches Popular and Detail like the parsers do, counting
+// upstream loads.
+type failingScraper struct {
+	articles []models.Article
+	err      error
+	cache    utils.CacheOps
+	loads    *int
+}
+
+func (f failingScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	return f.articles, f.err
+}
+
+func (f failingScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return utils.FetchAs(ctx, f.cache, "popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		*f.loads++
+		return f.articles, time.Minute, f.err
+	})
+}
+
+func (f failingScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	return utils.FetchAs(ctx, f.cache, url, func(ctx context.Context) (models.Article, time.Duration, error) {
+		*f.loads++
+		if f.err != nil {
+			return models.Article{}, 0, f.err
+		}
+		return models.Article{URL: url, Title: "Live", Content: "<p>live</p>"}, time.Minute, nil
+	})
+}
+
+func TestScraperArchivesAndFallsBack(t *testing.T) {
+	//prepare data
+	a := openArchive(t)
+	ctx := context.Background()
+	detailUrl := "https://www.kompas.com/read/1?page=all"
+	var loads int
+	live := archive.Scraper{Source: "kompas", Next: failingScraper{
+		articles: []models.Article{{SourceUrl: detailUrl, Title: "Live"}},
+		cache:    utils.NewCache(),
+		loads:    &loads,
+	}, Archive: a}
+	down := archive.Scraper{Source: "kompas", Next: failingScraper{
+		err:   errors.New("error: status code 404"),
+		cache: utils.NewCache(),
+		loads: &loads,
+	}, Archive: a}
+
+	//do test
+	_, err := live.Popular(ctx, utils.LinkBuilder{})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Flush(ctx))
+	_, err = down.Detail(ctx, detailUrl, utils.LinkBuilder{})
+	// only a list entry is archived so far, nothing to serve
+	assert.EqualError(t, err, "error: status code 404")
+
+	_, err = live.Detail(ctx, detailUrl, utils.LinkBuilder{})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Flush(ctx))
+	article, err := down.Detail(ctx, detailUrl, utils.LinkBuilder{})
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>live</p>", article.Content)
+	assert.Equal(t, detailUrl, article.URL)
+}
+
+func TestScraperSkipsCacheHits(t *testing.T) {
+	//prepare data
+	a := openArchive(t)
+	ctx := context.Background()
+	var loads int
+	live := archive.Scraper{Source: "kompas", Next: failingScraper{
+		articles: []models.Article{{SourceUrl: "https://www.kompas.com/read/1", Title: "Live"}},
+		cache:    utils.NewCache(),
+		loads:    &loads,
+	}, Archive: a}
+	_, err := live.Popular(ctx, utils.LinkBuilder{})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Flush(ctx))
+	// a row changed behind the cache's back is only overwritten by a new load
+	assert.Nil(t, a.Save(ctx, "kompas", models.Article{SourceUrl: "https://www.kompas.com/read/1", Title: "Edited"}))
+
+	//do test
+	_, err = live.Popular(ctx, utils.LinkBuilder{})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Flush(ctx))
+
+	//assertions
+	assert.Equal(t, 1, loads)
+	article, found, err := a.Get(ctx, "kompas", "https://www.kompas.com/read/1")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Edited", article.Title)
+}
+
+func titlesOf(articles []models.Article) []string {
+	titles := []string{}
+	for _, article := range articles {
+		titles = append(titles, article.Title)
+	}
+	return titles
+}
@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// Scraper archives what Next fetches for Source, and serves the archived copy
+// of an article when its detail page can no longer be fetched. Saves are
+// queued with SaveAsync, off the request path. Popular lists and details are
+// archived from inside Next's cache loads (utils.WithLoadWrapper), so cache
+// hits aren't written again; search and index results are never cached and
+// are archived as they come.
+type Scraper struct {
+	Source  string
+	Next    scraper.NewsScraper
+	Archive *Archive
+}
+
+func (s Scraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	articles, err := s.Next.Search(ctx, keyword, page, links)
+	if err == nil {
+		s.Archive.SaveAsync(s.Source, articles...)
+	}
+	return articles, err
+}
+
+func (s Scraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return s.Next.Popular(utils.WithLoadWrapper(ctx, s.archiveLoads), links)
+}
+
+// Latest forwards to Next when it is a scraper.Indexer.
+func (s Scraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexer, ok := s.Next.(scraper.Indexer)
+	if !ok {
+		return nil, fmt.Errorf("%v has no index", s.Source)
+	}
+	articles, err := indexer.Latest(ctx, links)
+	if err == nil {
+		s.Archive.SaveAsync(s.Source, articles...)
+	}
+	return articles, err
+}
+
+func (s Scraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	article, err := s.Next.Detail(utils.WithLoadWrapper(ctx, s.archiveLoads), url, links)
+	if err == nil {
+		return article, nil
+	}
+
+	archived, found, archiveErr := s.Archive.Get(ctx, s.Source, url)
+	if archiveErr != nil {
+		log.Printf("archive lookup %s failed: %v", url, archiveErr)
+	}
+	if found && archived.Content != "" {
+		log.Printf("%s detail failed (%v), serving archived copy", s.Source, err)
+		return archived, nil
+	}
+	return article, err
+}
+
+// archiveLoads queues the articles a successful cache load returns.
+func (s Scraper) archiveLoads(load utils.Loader) utils.Loader {
+	return func(ctx context.Context) (interface{}, time.Duration, error) {
+		value, ttl, err := load(ctx)
+		if err != nil {
+			return value, ttl, err
+		}
+		switch loaded := value.(type) {
+		case models.Article:
+			s.Archive.SaveAsync(s.Source, loaded)
+		case []models.Article:
+			s.Archive.SaveAsync(s.Source, loaded...)
+		}
+		return value, ttl, err
+	}
+}
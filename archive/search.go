@@ -0,0 +1,202 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/mattn/go-sqlite3"
+)
+
+// columnWeights boosts title matches over body matches when ranking, in
+// articles_fts column order.
+var columnWeights = []float64{3, 1}
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// index (re)writes the full-text entry for article id. body combines the
+// description with the visible text of the cleaned content.
+func index(ctx context.Context, tx *sql.Tx, id int64, title, shortDesc, content string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM articles_fts WHERE docid = ?`, id); err != nil {
+		return err
+	}
+	body := strings.Join(analyze(shortDesc+"\n"+htmlText(content)), " ")
+	_, err := tx.ExecContext(ctx, `INSERT INTO articles_fts (docid, title, body) VALUES (?, ?, ?)`,
+		id, strings.Join(analyze(title), " "), body)
+	return err
+}
+
+func reindexAll(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, title, short_desc, content FROM articles`)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id                        int64
+		title, shortDesc, content string
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.title, &r.shortDesc, &r.content); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range all {
+		if err := index(ctx, tx, r.id, r.title, r.shortDesc, r.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search runs a full-text query over archived titles and content, best match
+// first. Words are stemmed, so "banjir" also finds "kebanjiran". Quoted
+// phrases ("harga beras") must appear in that order and an uppercase OR
+// between two terms matches either; every other term is required. filter
+// narrows the results and pages through them. Ranking and paging happen in
+// SQLite, and content is left out since list results don't carry it.
+func (a *Archive) Search(ctx context.Context, q string, filter Filter, links utils.LinkBuilder) ([]models.Article, error) {
+	match := matchExpression(q)
+	if match == "" {
+		return []models.Article{}, nil
+	}
+
+	where, args := filter.where()
+	where = append([]string{"articles_fts MATCH ?"}, where...)
+	args = append([]any{match}, args...)
+	limit := -1
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	args = append(args, limit, filter.Offset)
+	rows, err := a.db.QueryContext(ctx, `SELECT `+listColumns+`
+		FROM articles_fts JOIN articles a ON a.id = articles_fts.docid
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+bm25Func+`(matchinfo(articles_fts, 'pcnalx')) DESC, a.published_unix DESC NULLS LAST, a.id DESC
+		LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanListEntries(rows, links)
+}
+
+// listColumns are columns without a.content, for list results.
+const listColumns = `a.source, a.url_key, a.source_url, a.detail_url, a.title, a.short_desc, a.author, a.date, a.published_at, a.img_url`
+
+// scanListEntries reads rows of listColumns as list results.
+func scanListEntries(rows *sql.Rows, links utils.LinkBuilder) ([]models.Article, error) {
+	articles := []models.Article{}
+	for rows.Next() {
+		var s storedArticle
+		var publishedAt sql.NullString
+		if err := rows.Scan(&s.article.Source, &s.article.ID, &s.article.SourceUrl, &s.detailURL, &s.article.Title,
+			&s.article.ShortDesc, &s.article.Author, &s.article.Date, &publishedAt, &s.article.ImgUrl); err != nil {
+			return nil, err
+		}
+		s.setPublishedAt(publishedAt)
+		articles = append(articles, s.listEntry(links))
+	}
+	return articles, rows.Err()
+}
+
+// bm25Func is the SQL name bm25 is registered under on every connection.
+const bm25Func = "gober_bm25"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc(bm25Func, bm25, true)
+		},
+	})
+}
+
+// matchExpression translates a user query into an FTS4 MATCH expression over
+// the analyzed columns. Stop words are dropped on both sides, so phrases
+// still line up.
+func matchExpression(q string) string {
+	var terms []string
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			// inside quotes
+			if stems := analyze(part); len(stems) > 0 {
+				terms = append(terms, `"`+strings.Join(stems, " ")+`"`)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if word == "OR" {
+				terms = append(terms, "OR")
+				continue
+			}
+			for _, s := range analyze(word) {
+				terms = append(terms, s)
+			}
+		}
+	}
+
+	// OR is binary: drop it where it lacks an operand on either side.
+	var expr []string
+	for i, term := range terms {
+		if term == "OR" && (len(expr) == 0 || expr[len(expr)-1] == "OR" || i == len(terms)-1) {
+			continue
+		}
+		expr = append(expr, term)
+	}
+	return strings.Join(expr, " ")
+}
+
+// bm25 scores a row from its matchinfo 'pcnalx' blob: phrase and column
+// counts, row count, average and current column lengths, then per phrase and
+// column the hits in this row, hits in all rows and rows with a hit.
+func bm25(info []byte) float64 {
+	values := make([]uint32, len(info)/4)
+	for i := range values {
+		values[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(values) < 3 {
+		return 0
+	}
+	phrases, cols, rowCount := int(values[0]), int(values[1]), float64(values[2])
+	avgLen := values[3 : 3+cols]
+	rowLen := values[3+cols : 3+2*cols]
+	hits := values[3+2*cols:]
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < cols; c++ {
+			x := hits[3*(p*cols+c):]
+			tf, docs := float64(x[0]), float64(x[2])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log((rowCount-docs+0.5)/(docs+0.5) + 1)
+			norm := 1 - bm25B
+			if avgLen[c] > 0 {
+				norm += bm25B * float64(rowLen[c]) / float64(avgLen[c])
+			}
+			weight := 1.0
+			if c < len(columnWeights) {
+				weight = columnWeights[c]
+			}
+			score += weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return score
+}
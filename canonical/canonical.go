@@ -0,0 +1,94 @@
+// Package canonical gives every article one identity, however a site links
+// to it: with or without ?single=1, ?page=all or tracking parameters, on the
+// mobile host or as an AMP page.
+package canonical
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/akhmadreiza/gober/models"
+)
+
+// URL normalizes raw to https on the bare host, without query string,
+// fragment, mobile or AMP variants. It identifies an article; it isn't
+// meant to be fetched. Unparseable input is returned as is.
+func URL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, prefix := range hostPrefixes {
+			if rest, ok := strings.CutPrefix(host, prefix); ok && strings.Contains(rest, ".") {
+				host, trimmed = rest, true
+			}
+		}
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "amp" {
+		segments = segments[1:]
+	}
+	if len(segments) > 0 && segments[len(segments)-1] == "amp" {
+		segments = segments[:len(segments)-1]
+	}
+	path := strings.Join(segments, "/")
+	if path != "" {
+		path = "/" + path
+	}
+	return "https://" + host + path
+}
+
+// hostPrefixes are subdomains that serve the same articles as the bare host.
+var hostPrefixes = []string{"www.", "m.", "mobile.", "amp."}
+
+// ID returns a stable identifier for the article at raw, unique within its
+// source: the site's own article ID where its URLs carry one (d-7666179 on
+// Detik, read/2024/05/01/12345671 on Kompas), otherwise the host and path
+// of its canonical URL.
+func ID(raw string) string {
+	canonical := URL(raw)
+	parsed, err := url.Parse(canonical)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	for _, rule := range idRules {
+		if parsed.Host != rule.host && !strings.HasSuffix(parsed.Host, "."+rule.host) {
+			continue
+		}
+		if match := rule.pattern.FindStringSubmatch(parsed.Path); match != nil {
+			return rule.prefix + match[1]
+		}
+	}
+	return strings.TrimPrefix(canonical, "https://")
+}
+
+// idRules find a site's article ID in the canonical path.
+var idRules = []struct {
+	host    string
+	pattern *regexp.Regexp
+	prefix  string
+}{
+	{host: "detik.com", pattern: regexp.MustCompile(`/d-(\d+)(?:/|$)`), prefix: "d-"},
+	{host: "kompas.com", pattern: regexp.MustCompile(`/read/(\d{4}/\d{2}/\d{2}/\d+)(?:/|$)`), prefix: "read/"},
+	{host: "tribunnews.com", pattern: regexp.MustCompile(`/(\d{4}/\d{2}/\d{2}/[^/]+)$`)},
+	{host: "cnnindonesia.com", pattern: regexp.MustCompile(`/(\d{14}-\d+-\d+)(?:/|$)`)},
+}
+
+// Of returns article's ID, deriving it from SourceUrl (or URL) when the
+// article doesn't carry one yet.
+func Of(article models.Article) string {
+	if article.ID != "" {
+		return article.ID
+	}
+	raw := article.SourceUrl
+	if raw == "" {
+		raw = article.URL
+	}
+	return ID(raw)
+}
@@ -0,0 +1,46 @@
+package canonical_test
+
+import (
+	"testing"
+
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL(t *testing.T) {
+	cases := map[string]string{
+		"https://news.detik.com/berita/d-7666179/judul?single=1":                    "https://news.detik.com/berita/d-7666179/judul",
+		"http://WWW.Kompas.com/read/2024/05/01/12345671/judul/?page=all#komentar":   "https://kompas.com/read/2024/05/01/12345671/judul",
+		"https://m.tribunnews.com/amp/nasional/2024/05/01/judul-berita":             "https://tribunnews.com/nasional/2024/05/01/judul-berita",
+		"https://amp.kompas.com/nasional/read/2024/05/01/12345671/judul":            "https://kompas.com/nasional/read/2024/05/01/12345671/judul",
+		"https://www.cnnindonesia.com/nasional/20240501123456-20-1234567/judul/amp": "https://cnnindonesia.com/nasional/20240501123456-20-1234567/judul",
+		"not a url": "not a url",
+	}
+	for raw, expected := range cases {
+		assert.Equal(t, expected, canonical.URL(raw), raw)
+	}
+}
+
+func TestID(t *testing.T) {
+	//prepare data
+	detikVariants := []string{
+		"https://news.detik.com/berita/d-7666179/judul-berita?single=1",
+		"https://m.detik.com/news/berita/d-7666179/judul-berita",
+		"https://news.detik.com/amp/berita/d-7666179/judul-berita?utm_source=wa",
+	}
+	kompasVariants := []string{
+		"https://nasional.kompas.com/read/2024/05/01/12345671/judul?page=all",
+		"https://amp.kompas.com/nasional/read/2024/05/01/12345671/judul",
+	}
+
+	//assertions
+	for _, raw := range detikVariants {
+		assert.Equal(t, "d-7666179", canonical.ID(raw), raw)
+	}
+	for _, raw := range kompasVariants {
+		assert.Equal(t, "read/2024/05/01/12345671", canonical.ID(raw), raw)
+	}
+	assert.Equal(t, "2024/05/01/judul-berita", canonical.ID("https://m.tribunnews.com/amp/nasional/2024/05/01/judul-berita?page=2"))
+	assert.Equal(t, "20240501123456-20-1234567", canonical.ID("https://www.cnnindonesia.com/nasional/20240501123456-20-1234567/judul"))
+	assert.Equal(t, "example.com/a/b", canonical.ID("https://www.example.com/a/b/?x=1"))
+}
@@ -0,0 +1,216 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// Schedule controls how often a source is crawled. Each round waits
+// Interval plus a random delay of up to Jitter, so sources don't all hit
+// their sites at the same moment. A zero Interval disables the source.
+type Schedule struct {
+	Interval time.Duration
+	Jitter   time.Duration
+}
+
+// DefaultSchedule re-crawls just before the scrapers' 5 minute list cache
+// expires, so users never wait on a cold Popular.
+var DefaultSchedule = Schedule{Interval: 4 * time.Minute, Jitter: 20 * time.Second}
+
+// DefaultMaxDetails caps how many new articles' details one round fetches.
+const DefaultMaxDetails = 20
+
+// ParseSchedules parses "default=4m,detik=2m,tribun=off" into per-source
+// schedules. The default entry applies to sources not listed; off (or 0)
+// disables crawling. Jitter is a tenth of each interval.
+func ParseSchedules(s string) (Schedule, map[string]Schedule, error) {
+	def := DefaultSchedule
+	perSource := map[string]Schedule{}
+	if strings.TrimSpace(s) == "" {
+		return def, perSource, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return def, nil, fmt.Errorf("invalid crawl interval %q", pair)
+		}
+		schedule := Schedule{}
+		if value = strings.TrimSpace(value); value != "off" {
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval < 0 {
+				return def, nil, fmt.Errorf("invalid crawl interval %q", pair)
+			}
+			schedule = Schedule{Interval: interval, Jitter: interval / 10}
+		}
+		if name = strings.TrimSpace(name); name == "default" {
+			def = schedule
+		} else {
+			perSource[name] = schedule
+		}
+	}
+	return def, perSource, nil
+}
+
+// Crawler periodically fetches every source's popular list and index page
+// ahead of users, then the details of articles it hasn't seen yet. Since it
+// goes through the registered scrapers, their caches (and the archive, when
+// enabled) are filled as a side effect.
+type Crawler struct {
+	Registry *scraper.Registry
+	Default  Schedule
+	// Schedules overrides Default per source name.
+	Schedules  map[string]Schedule
+	MaxDetails int
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start launches one crawl loop per source with a non-zero interval.
+func (c *Crawler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	for _, src := range c.Registry.Sources() {
+		schedule, ok := c.Schedules[src.Name]
+		if !ok {
+			schedule = c.Default
+		}
+		if schedule.Interval <= 0 {
+			continue
+		}
+		log.Printf("crawling %s every %v", src.Name, schedule.Interval)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.loop(ctx, src, schedule)
+		}()
+	}
+}
+
+// Stop cancels in-flight rounds and waits for the loops to exit, or for ctx
+// to expire.
+func (c *Crawler) Stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Crawler) loop(ctx context.Context, src scraper.Source, schedule Schedule) {
+	// seen holds the articles of the last round whose details are fetched.
+	seen := map[string]bool{}
+	// Spread the first rounds out too.
+	wait := jitter(schedule.Jitter)
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		seen = c.crawl(ctx, src, seen)
+		wait = schedule.Interval + jitter(schedule.Jitter)
+	}
+}
+
+// crawl runs one round for src and returns the updated seen set.
+func (c *Crawler) crawl(ctx context.Context, src scraper.Source, seen map[string]bool) map[string]bool {
+	var articles []models.Article
+	if src.Capabilities.Popular {
+		popular, err := src.Scraper.Popular(utils.WithCacheRefresh(ctx), utils.LinkBuilder{})
+		if err != nil {
+			log.Printf("crawl %s popular: %v", src.Name, err)
+		}
+		articles = append(articles, popular...)
+	}
+	if indexer, ok := src.Scraper.(scraper.Indexer); ok && src.Capabilities.Index {
+		latest, err := indexer.Latest(ctx, utils.LinkBuilder{})
+		if err != nil {
+			log.Printf("crawl %s index: %v", src.Name, err)
+		}
+		articles = append(articles, latest...)
+	}
+
+	maxDetails := c.MaxDetails
+	if maxDetails == 0 {
+		maxDetails = DefaultMaxDetails
+	}
+
+	// Only keep keys still listed, so seen doesn't grow without bound.
+	next := map[string]bool{}
+	tried := map[string]bool{}
+	fetched := 0
+	for _, article := range articles {
+		detailURL := detailURLOf(article)
+		if detailURL == "" {
+			continue
+		}
+		// Lists come from the sites themselves, so apply the same
+		// allow-list as the /article endpoint.
+		if owner, ok := c.Registry.SourceForURL(detailURL); !ok || owner.Name != src.Name {
+			log.Printf("crawl %s: skipping detail %s outside the source's hosts", src.Name, detailURL)
+			continue
+		}
+		key := canonical.ID(detailURL)
+		if seen[key] || next[key] {
+			next[key] = true
+			continue
+		}
+		if tried[key] || !src.Capabilities.Detail || fetched >= maxDetails || ctx.Err() != nil {
+			continue
+		}
+		tried[key] = true
+		fetched++
+		if _, err := src.Scraper.Detail(ctx, detailURL, utils.LinkBuilder{}); err != nil {
+			// Not marked seen, so the next round retries it.
+			log.Printf("crawl %s detail %s: %v", src.Name, detailURL, err)
+			continue
+		}
+		next[key] = true
+	}
+
+	log.Printf("crawled %s: %d articles, %d new details", src.Name, len(articles), fetched)
+	return next
+}
+
+// detailURLOf extracts the upstream URL from an article's Gober detail link.
+func detailURLOf(article models.Article) string {
+	parsed, err := url.Parse(article.URL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("detailUrl")
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
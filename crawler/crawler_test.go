@@ -0,0 +1,165 @@
+package crawler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/crawler"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingScraper serves a fixed popular and index list and records calls.
+type recordingScraper struct {
+	mu        sync.Mutex
+	popular   []models.Article
+	latest    []models.Article
+	failing   map[string]bool
+	rounds    int
+	refreshed bool
+	details   []string
+}
+
+func (r *recordingScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	return nil, nil
+}
+
+func (r *recordingScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rounds++
+	r.refreshed = utils.CacheRefresh(ctx)
+	return r.popular, nil
+}
+
+func (r *recordingScraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return r.latest, nil
+}
+
+func (r *recordingScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.details = append(r.details, url)
+	if r.failing[url] {
+		return models.Article{}, errors.New("error: status code 500")
+	}
+	return models.Article{URL: url}, nil
+}
+
+func (r *recordingScraper) snapshot() (int, bool, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rounds, r.refreshed, append([]string{}, r.details...)
+}
+
+func listed(urls ...string) []models.Article {
+	var articles []models.Article
+	for _, u := range urls {
+		articles = append(articles, models.Article{URL: utils.LinkBuilder{}.Article("detik", u), SourceUrl: u})
+	}
+	return articles
+}
+
+func newCrawler(t *testing.T, s *recordingScraper, schedule crawler.Schedule) *crawler.Crawler {
+	registry := scraper.NewRegistry()
+	assert.Nil(t, registry.Register(scraper.Source{
+		Name:         "detik",
+		Hosts:        []string{"detik.com"},
+		Capabilities: scraper.Capabilities{Popular: true, Detail: true, Index: true},
+		Scraper:      s,
+	}))
+	return &crawler.Crawler{Registry: registry, Default: schedule, MaxDetails: 2}
+}
+
+func TestCrawlerFetchesNewDetailsOnce(t *testing.T) {
+	//prepare data
+	s := &recordingScraper{
+		popular: listed("https://news.detik.com/d-1?single=1", "http://169.254.169.254/d-9", "https://news.detik.com/d-2?single=1"),
+		latest:  listed("https://news.detik.com/d-2?single=1", "https://news.detik.com/d-3?single=1", "https://news.detik.com/d-4?single=1"),
+		failing: map[string]bool{"https://news.detik.com/d-2?single=1": true},
+	}
+	c := newCrawler(t, s, crawler.Schedule{Interval: 5 * time.Millisecond})
+
+	//do test
+	c.Start()
+	assert.Eventually(t, func() bool {
+		rounds, _, _ := s.snapshot()
+		return rounds >= 4
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, c.Stop(context.Background()))
+	_, refreshed, details := s.snapshot()
+
+	//assertions
+	assert.True(t, refreshed)
+	// d-2 keeps failing and is retried; the others are fetched once, two per round
+	assert.Equal(t, 1, count(details, "https://news.detik.com/d-1?single=1"))
+	assert.Equal(t, 1, count(details, "https://news.detik.com/d-3?single=1"))
+	assert.Equal(t, 1, count(details, "https://news.detik.com/d-4?single=1"))
+	assert.Greater(t, count(details, "https://news.detik.com/d-2?single=1"), 1)
+	assert.Equal(t, 0, count(details, "http://169.254.169.254/d-9"))
+	assert.Equal(t, []string{"https://news.detik.com/d-1?single=1", "https://news.detik.com/d-2?single=1"}, details[:2])
+}
+
+func TestCrawlerStopsBeforeFirstRound(t *testing.T) {
+	//prepare data
+	s := &recordingScraper{}
+	c := newCrawler(t, s, crawler.Schedule{Interval: time.Hour, Jitter: time.Hour})
+
+	//do test
+	c.Start()
+	err := c.Stop(context.Background())
+
+	//assertions
+	assert.Nil(t, err)
+	rounds, _, _ := s.snapshot()
+	assert.Equal(t, 0, rounds)
+}
+
+func TestCrawlerSkipsDisabledSources(t *testing.T) {
+	//prepare data
+	s := &recordingScraper{}
+	c := newCrawler(t, s, crawler.Schedule{Interval: 5 * time.Millisecond})
+	c.Schedules = map[string]crawler.Schedule{"detik": {}}
+
+	//do test
+	c.Start()
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(t, c.Stop(context.Background()))
+
+	//assertions
+	rounds, _, _ := s.snapshot()
+	assert.Equal(t, 0, rounds)
+}
+
+func TestParseSchedules(t *testing.T) {
+	def, perSource, err := crawler.ParseSchedules("")
+	assert.Nil(t, err)
+	assert.Equal(t, crawler.DefaultSchedule, def)
+	assert.Empty(t, perSource)
+
+	def, perSource, err = crawler.ParseSchedules("default=10m, detik=2m, tribun=off")
+	assert.Nil(t, err)
+	assert.Equal(t, crawler.Schedule{Interval: 10 * time.Minute, Jitter: time.Minute}, def)
+	assert.Equal(t, crawler.Schedule{Interval: 2 * time.Minute, Jitter: 12 * time.Second}, perSource["detik"])
+	assert.Equal(t, crawler.Schedule{}, perSource["tribun"])
+
+	_, _, err = crawler.ParseSchedules("detik")
+	assert.EqualError(t, err, `invalid crawl interval "detik"`)
+	_, _, err = crawler.ParseSchedules("detik=soon")
+	assert.EqualError(t, err, `invalid crawl interval "detik=soon"`)
+}
+
+func count(list []string, s string) int {
+	n := 0
+	for _, item := range list {
+		if item == s {
+			n++
+		}
+	}
+	return n
+}
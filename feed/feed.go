@@ -0,0 +1,345 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// Format is a syndication format an article list can be rendered as.
+type Format string
+
+const (
+	None     Format = ""
+	RSS      Format = "rss"
+	Atom     Format = "atom"
+	JSONFeed Format = "jsonfeed"
+)
+
+var contentTypes = map[Format]string{
+	RSS:      "application/rss+xml; charset=utf-8",
+	Atom:     "application/atom+xml; charset=utf-8",
+	JSONFeed: "application/feed+json; charset=utf-8",
+}
+
+// ContentType returns the response Content-Type for f.
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Negotiate picks the feed format from an explicit format param, falling back
+// to the Accept header. None means the regular JSON API response.
+func Negotiate(format, accept string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case RSS:
+		return RSS, nil
+	case Atom:
+		return Atom, nil
+	case JSONFeed:
+		return JSONFeed, nil
+	case None:
+	default:
+		return None, fmt.Errorf("param format must be one of rss, atom, jsonfeed")
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/rss+xml":
+			return RSS, nil
+		case "application/atom+xml":
+			return Atom, nil
+		case "application/feed+json":
+			return JSONFeed, nil
+		}
+	}
+	return None, nil
+}
+
+// Feed is a format-neutral article feed.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the human-readable page for the feed, SelfLink the feed URL itself.
+	Link     string
+	SelfLink string
+	Updated  time.Time
+	Items    []Item
+}
+
+// Item is one feed entry. ID is the upstream article URL.
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Author    string
+	ImageURL  string
+	Published *time.Time
+}
+
+// FromArticles builds feed items linking to Gober's ad-free reader page.
+// defaultSource is used for articles that don't carry their own source.
+func FromArticles(f Feed, articles []models.Article, links utils.LinkBuilder, defaultSource string) Feed {
+	f.Items = make([]Item, 0, len(articles))
+	for _, article := range articles {
+		source := article.Source
+		if source == "" {
+			source = defaultSource
+		}
+		id := article.SourceUrl
+		if id == "" {
+			id = article.URL
+		}
+		f.Items = append(f.Items, Item{
+			ID:        id,
+			Title:     strings.TrimSpace(article.Title),
+			Link:      links.Reader(source, id),
+			Summary:   strings.TrimSpace(article.ShortDesc),
+			Author:    strings.TrimSpace(article.Author),
+			ImageURL:  article.ImgUrl,
+			Published: article.PublishedAt,
+		})
+		if article.PublishedAt != nil && article.PublishedAt.After(f.Updated) {
+			f.Updated = *article.PublishedAt
+		}
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+	return f
+}
+
+// Render encodes f in the given format.
+func Render(f Feed, format Format) ([]byte, error) {
+	switch format {
+	case RSS:
+		return renderRSS(f)
+	case Atom:
+		return renderAtom(f)
+	case JSONFeed:
+		return renderJSONFeed(f)
+	}
+	return nil, fmt.Errorf("unsupported feed format %q", format)
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	Author      string        `xml:"dc:creator,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func renderRSS(f Feed) ([]byte, error) {
+	doc := rssDoc{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			AtomLink:      atomLink{Href: f.SelfLink, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+		},
+	}
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			Description: item.Summary,
+			Author:      item.Author,
+		}
+		if item.Published != nil {
+			ri.PubDate = item.Published.Format(time.RFC1123Z)
+		}
+		if item.ImageURL != "" {
+			// Length is unknown without fetching the image; 0 is the accepted convention.
+			ri.Enclosure = &rssEnclosure{URL: item.ImageURL, Type: imageType(item.ImageURL)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type atomDoc struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Author    *atomAuthor `xml:"author"`
+	Summary   string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+func renderAtom(f Feed) ([]byte, error) {
+	doc := atomDoc{
+		ID:      f.SelfLink,
+		Title:   f.Title,
+		Updated: f.Updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: "Gober"},
+		Links: []atomLink{
+			{Href: f.SelfLink, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, item := range f.Items {
+		updated := f.Updated
+		entry := atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Summary: item.Summary,
+			Links:   []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+		}
+		if item.Published != nil {
+			updated = *item.Published
+			entry.Published = item.Published.Format(time.RFC3339)
+		}
+		entry.Updated = updated.Format(time.RFC3339)
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.ImageURL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.ImageURL, Rel: "enclosure", Type: imageType(item.ImageURL)})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type jsonFeedDoc struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	Summary       string           `json:"summary,omitempty"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func renderJSONFeed(f Feed) ([]byte, error) {
+	doc := jsonFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.SelfLink,
+		Description: f.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range f.Items {
+		ji := jsonFeedItem{
+			ID:          item.ID,
+			URL:         item.Link,
+			Title:       item.Title,
+			Summary:     item.Summary,
+			ContentText: item.Title,
+			Image:       item.ImageURL,
+		}
+		if item.Published != nil {
+			ji.DatePublished = item.Published.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, ji)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// imageType guesses an image MIME type from the URL's extension.
+func imageType(imageURL string) string {
+	ext := strings.ToLower(path.Ext(strings.SplitN(imageURL, "?", 2)[0]))
+	switch ext {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,128 @@
+package feed_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/feed"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeed() feed.Feed {
+	published := time.Date(2024, 12, 2, 10, 15, 0, 0, time.FixedZone("WIB", 7*60*60))
+	articles := []models.Article{
+		{
+			Title:       "Banjir di Jakarta",
+			SourceUrl:   "https://news.detik.com/berita/d-1/banjir",
+			ShortDesc:   "Hujan deras sejak pagi",
+			Author:      "Tim detikcom",
+			ImgUrl:      "https://akcdn.detik.net.id/foto.png?w=800",
+			PublishedAt: &published,
+		},
+		{
+			Title:     "Harga beras naik",
+			SourceUrl: "https://www.kompas.com/read/2",
+			Source:    "kompas",
+		},
+	}
+	return feed.FromArticles(feed.Feed{
+		Title:    "Gober - Terpopuler (detik)",
+		Link:     "http://gober.test/?source=detik",
+		SelfLink: "http://gober.test/articles/popular?source=detik&format=rss",
+	}, articles, utils.LinkBuilder{BaseURL: "http://gober.test"}, "detik")
+}
+
+func TestNegotiate(t *testing.T) {
+	format, err := feed.Negotiate("Atom", "")
+	assert.Nil(t, err)
+	assert.Equal(t, feed.Atom, format)
+
+	format, err = feed.Negotiate("", "text/html, application/feed+json;q=0.9")
+	assert.Nil(t, err)
+	assert.Equal(t, feed.JSONFeed, format)
+
+	format, err = feed.Negotiate("", "application/json")
+	assert.Nil(t, err)
+	assert.Equal(t, feed.None, format)
+
+	_, err = feed.Negotiate("csv", "")
+	assert.EqualError(t, err, "param format must be one of rss, atom, jsonfeed")
+}
+
+func TestFromArticles(t *testing.T) {
+	f := testFeed()
+
+	//assertions
+	assert.Len(t, f.Items, 2)
+	assert.Equal(t, "http://gober.test/detail?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fberita%2Fd-1%2Fbanjir", f.Items[0].Link)
+	assert.Equal(t, "http://gober.test/detail?source=kompas&detailUrl=https%3A%2F%2Fwww.kompas.com%2Fread%2F2", f.Items[1].Link)
+	assert.True(t, f.Updated.Equal(*f.Items[0].Published))
+}
+
+func TestRenderRSS(t *testing.T) {
+	//do test
+	body, err := feed.Render(testFeed(), feed.RSS)
+	assert.Nil(t, err)
+
+	//assertions
+	var doc struct {
+		Items []struct {
+			Title     string `xml:"title"`
+			GUID      string `xml:"guid"`
+			PubDate   string `xml:"pubDate"`
+			Enclosure struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"channel>item"`
+	}
+	assert.Nil(t, xml.Unmarshal(body, &doc))
+	assert.Len(t, doc.Items, 2)
+	assert.Equal(t, "Banjir di Jakarta", doc.Items[0].Title)
+	assert.Equal(t, "https://news.detik.com/berita/d-1/banjir", doc.Items[0].GUID)
+	assert.Equal(t, "Mon, 02 Dec 2024 10:15:00 +0700", doc.Items[0].PubDate)
+	assert.Equal(t, "image/png", doc.Items[0].Enclosure.Type)
+	assert.Empty(t, doc.Items[1].PubDate)
+	assert.Empty(t, doc.Items[1].Enclosure.URL)
+}
+
+func TestRenderAtom(t *testing.T) {
+	//do test
+	body, err := feed.Render(testFeed(), feed.Atom)
+	assert.Nil(t, err)
+
+	//assertions
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Published string `xml:"published"`
+			Author    string `xml:"author>name"`
+		} `xml:"entry"`
+	}
+	assert.Nil(t, xml.Unmarshal(body, &doc))
+	assert.Len(t, doc.Entries, 2)
+	assert.Equal(t, "2024-12-02T10:15:00+07:00", doc.Entries[0].Published)
+	assert.Equal(t, "Tim detikcom", doc.Entries[0].Author)
+	assert.Empty(t, doc.Entries[1].Published)
+}
+
+func TestRenderJSONFeed(t *testing.T) {
+	//do test
+	body, err := feed.Render(testFeed(), feed.JSONFeed)
+	assert.Nil(t, err)
+
+	//assertions
+	var doc map[string]any
+	assert.Nil(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	items := doc["items"].([]any)
+	assert.Len(t, items, 2)
+	first := items[0].(map[string]any)
+	assert.Equal(t, "https://akcdn.detik.net.id/foto.png?w=800", first["image"])
+	assert.Equal(t, "2024-12-02T10:15:00+07:00", first["date_published"])
+}
@@ -4,6 +4,7 @@ go 1.23
 
 require (
 	github.com/PuerkitoBio/goquery v1.10.0
+	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/stretchr/testify v1.9.0
 )
 
@@ -40,5 +41,5 @@ require (
 require (
 	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/gin-gonic/gin v1.10.0
-	golang.org/x/net v0.29.0 // indirect
+	golang.org/x/net v0.29.0
 )
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/akhmadreiza/gober/feed"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/gin-gonic/gin"
+)
+
+// wib is the zone date-only since/until values are interpreted in.
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// listQuery holds the params shared by the list endpoints: pagination
+// (cursor, or page with limit), since/until (RFC 3339 or YYYY-MM-DD),
+// sort=newest and the output format. Time filters and sorting apply to each
+// upstream page before it is cut to limit; sites list search results newest
+// first already, so sort mostly matters for popular lists.
+type listQuery struct {
+	format feed.Format
+	cursor scraper.Cursor
+	limit  int
+	since  *time.Time
+	until  *time.Time
+	sort   string
+}
+
+func parseListQuery(ginContext *gin.Context) (listQuery, error) {
+	q := listQuery{cursor: scraper.FirstCursor}
+	var err error
+
+	if limit := ginContext.Query("limit"); limit != "" {
+		q.limit, err = strconv.Atoi(limit)
+		if err != nil || q.limit < 1 || q.limit > scraper.MaxPageLimit {
+			return q, fmt.Errorf("param limit must be between 1 and %d", scraper.MaxPageLimit)
+		}
+	}
+
+	if cursor := ginContext.Query("cursor"); cursor != "" {
+		if q.cursor, err = scraper.DecodeCursor(cursor); err != nil {
+			return q, fmt.Errorf("param cursor is invalid")
+		}
+	} else if page := ginContext.Query("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("param page must be a positive number")
+		}
+		if q.limit == 0 {
+			return q, fmt.Errorf("param page requires limit")
+		}
+		q.cursor = scraper.Cursor{Page: 1, Offset: (n - 1) * q.limit}
+	}
+
+	if q.since, err = parseTimeParam("since", ginContext.Query("since"), false); err != nil {
+		return q, err
+	}
+	if q.until, err = parseTimeParam("until", ginContext.Query("until"), true); err != nil {
+		return q, err
+	}
+
+	if q.format, err = feed.Negotiate(ginContext.Query("format"), ginContext.GetHeader("Accept")); err != nil {
+		return q, err
+	}
+
+	q.sort = ginContext.Query("sort")
+	if q.sort != "" && q.sort != "newest" {
+		return q, fmt.Errorf("param sort must be newest")
+	}
+	return q, nil
+}
+
+// parseTimeParam accepts RFC 3339 or a plain date. A plain date used as an
+// upper bound covers the whole day.
+func parseTimeParam(name, value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, wib)
+	if err != nil {
+		return nil, fmt.Errorf("param %v must be RFC 3339 or YYYY-MM-DD", name)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// filtered wraps fetch so since/until and sort apply to each upstream page
+// before scraper.Paginate slices it, keeping limit, has_more and cursors
+// consistent with what the client receives.
+func (q listQuery) filtered(fetch scraper.PageFetcher) scraper.PageFetcher {
+	return func(page int) ([]models.Article, bool, error) {
+		articles, more, err := fetch(page)
+		if err != nil {
+			return articles, more, err
+		}
+		return q.apply(articles), more, nil
+	}
+}
+
+// apply filters and orders articles without modifying the input slice, which
+// may be shared with the cache.
+func (q listQuery) apply(articles []models.Article) []models.Article {
+	articles = scraper.FilterByPublishTime(articles, q.since, q.until)
+	if q.sort == "newest" {
+		sorted := make([]models.Article, len(articles))
+		copy(sorted, articles)
+		scraper.SortByPublishTime(sorted)
+		articles = sorted
+	}
+	return articles
+}
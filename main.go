@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/akhmadreiza/gober/archive"
+	"github.com/akhmadreiza/gober/crawler"
+	"github.com/akhmadreiza/gober/feed"
 	"github.com/akhmadreiza/gober/models"
 	"github.com/akhmadreiza/gober/parsers"
 	"github.com/akhmadreiza/gober/scraper"
@@ -20,22 +25,115 @@ import (
 )
 
 type GoberResp struct {
-	Status   string           `json:"status"`
-	Count    int              `json:"count"`
-	Articles []models.Article `json:"articles"`
+	Status   string                 `json:"status"`
+	Count    int                    `json:"count"`
+	Articles []models.Article       `json:"articles"`
+	Sources  []scraper.SourceResult `json:"sources,omitempty"`
+	// NextCursor and HasMore are only set on paginated list responses.
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
 }
 
 var httpClient *utils.RealHTTPClient
 var scrapeUtils utils.ScrapeUtils
-var cache *utils.Cache
+var cache utils.CacheOps
+var registry *scraper.Registry
+var articleArchive *archive.Archive
+var articleCrawler *crawler.Crawler
+var breakers = scraper.NewBreakers(scraper.DefaultBreakerThreshold, scraper.DefaultBreakerCooldown)
+var rankWeights = scraper.DefaultRankWeights
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.SetPrefix("[GOBER] ")
 
 	httpClient = utils.NewHTTPClient()
+	// Every fetch and redirect hop must stay on a registered news site and
+	// a public address.
+	utils.OutboundPolicy{AllowURL: func(u *url.URL) bool {
+		return registry.IsAllowedURL(u.String())
+	}}.Apply(httpClient.Client)
+	if err := configureHTTPClient(httpClient); err != nil {
+		log.Fatalf("invalid http config: %v", err)
+	}
+	hostDefault, hostOverrides, err := utils.ParseHostLimits(os.Getenv("GOBER_HOST_LIMITS"))
+	if err != nil {
+		log.Fatalf("invalid http config: %v", err)
+	}
+	httpClient.Hosts = utils.NewHostLimiter(hostDefault, hostOverrides)
+	// GOBER_ROBOTS_IGNORE lists hosts whose robots.txt the operator overrides.
+	if ignore := os.Getenv("GOBER_ROBOTS_IGNORE"); ignore != "" {
+		httpClient.Robots.Ignore = strings.Split(ignore, ",")
+	}
 	scrapeUtils = utils.NewScrapeUtils(*httpClient)
-	cache = utils.NewCache()
+	// GOBER_REDIS_URL shares one cache between replicas; without it each
+	// process keeps its own in memory.
+	if redisURL := os.Getenv("GOBER_REDIS_URL"); redisURL != "" {
+		cfg, err := utils.ParseRedisURL(redisURL)
+		if err != nil {
+			log.Fatalf("invalid cache config: %v", err)
+		}
+		redisCache := utils.NewRedisCache(cfg)
+		if err := redisCache.Ping(); err != nil {
+			log.Printf("redis at %s is not reachable yet: %v", cfg.Addr, err)
+		}
+		defer redisCache.Close()
+		cache = redisCache
+	} else {
+		memoryCache := utils.NewCache()
+		if err := configureCache(memoryCache); err != nil {
+			log.Fatalf("invalid cache config: %v", err)
+		}
+		stopJanitor := memoryCache.StartJanitor(time.Minute)
+		defer stopJanitor()
+		cache = memoryCache
+	}
+
+	registry, err = parsers.NewRegistry(httpClient, scrapeUtils, cache)
+	if err != nil {
+		log.Fatalf("failed to register sources: %v", err)
+	}
+	if err := configureBreakers(breakers); err != nil {
+		log.Fatalf("invalid breaker config: %v", err)
+	}
+	// The breaker sits below the archive and the scrapers' caches, so an
+	// open circuit still serves cached lists and archived details.
+	registry.Decorate(func(src scraper.Source) scraper.NewsScraper {
+		return scraper.Guarded{Source: src.Name, Next: src.Scraper, Breaker: breakers.For(src.Name)}
+	})
+
+	// GOBER_ARCHIVE_PATH="" turns the archive off.
+	archivePath, set := os.LookupEnv("GOBER_ARCHIVE_PATH")
+	if !set {
+		archivePath = "gober.db"
+	}
+	if archivePath != "" {
+		articleArchive, err = archive.Open(archivePath)
+		if err != nil {
+			log.Fatalf("failed to open archive %s: %v", archivePath, err)
+		}
+		defer articleArchive.Close()
+		registry.Decorate(func(src scraper.Source) scraper.NewsScraper {
+			return archive.Scraper{Source: src.Name, Next: src.Scraper, Archive: articleArchive}
+		})
+		log.Printf("archiving articles to %s", archivePath)
+	}
+
+	rankWeights, err = scraper.ParseRankWeights(os.Getenv("GOBER_POPULAR_WEIGHTS"))
+	if err != nil {
+		log.Fatalf("invalid GOBER_POPULAR_WEIGHTS: %v", err)
+	}
+
+	crawlDefault, crawlSchedules, err := crawler.ParseSchedules(os.Getenv("GOBER_CRAWL_INTERVALS"))
+	if err != nil {
+		log.Fatalf("invalid GOBER_CRAWL_INTERVALS: %v", err)
+	}
+	articleCrawler = &crawler.Crawler{
+		Registry:  registry,
+		Default:   crawlDefault,
+		Schedules: crawlSchedules,
+	}
+	articleCrawler.Start()
 
 	initRouter()
 }
@@ -50,13 +148,18 @@ func initRouter() {
 	router := gin.Default()
 	router.Use(utils.RateLimitMiddleware())
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		health := gin.H{"status": "ok", "sources": breakers.Statuses()}
+		if stats, ok := cache.(interface{ Stats() utils.CacheStats }); ok {
+			health["cache"] = stats.Stats()
+		}
+		c.JSON(http.StatusOK, health)
 	})
 	router.Static("/static", "./static")
 	router.NoRoute(serveStatic)
 	router.GET("/articles/popular", getPopularArticle)
 	router.GET("/articles", searchArticle)
 	router.GET("/article", articleDetail)
+	router.GET("/sources", listSources)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -84,22 +187,102 @@ func initRouter() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("forced shutdown: %v", err)
 	}
+	if err := articleCrawler.Stop(ctx); err != nil {
+		log.Printf("crawler did not stop in time: %v", err)
+	}
 	log.Println("server stopped")
 }
 
-// isAllowedURL rejects requests that don't target a known news domain,
-// preventing SSRF via the detailUrl parameter.
-func isAllowedURL(rawURL string) bool {
-	parsed, err := url.Parse(rawURL)
-	if err != nil || parsed.Host == "" {
-		return false
+// configureCache applies GOBER_CACHE_MAX_ENTRIES and GOBER_CACHE_MAX_MB;
+// 0 lifts the respective bound.
+func configureCache(c *utils.Cache) error {
+	if v := os.Getenv("GOBER_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("GOBER_CACHE_MAX_ENTRIES must be a non-negative number")
+		}
+		c.MaxEntries = n
+	}
+	if v := os.Getenv("GOBER_CACHE_MAX_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("GOBER_CACHE_MAX_MB must be a non-negative number")
+		}
+		c.MaxBytes = int64(n) << 20
+	}
+	return nil
+}
+
+// scrapeErrorStatus is the response status for a failed scrape: 403 when
+// the site's robots.txt forbids the fetch or it was redirected off the
+// allowed sites, 502 when the site sent something
+// Gober won't parse, 503 while the source's circuit breaker is open, 500
+// otherwise.
+func scrapeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrDisallowed), errors.Is(err, utils.ErrForbiddenDestination):
+		return http.StatusForbidden
+	case errors.Is(err, utils.ErrTooLarge), errors.Is(err, utils.ErrNotHTML):
+		return http.StatusBadGateway
+	case errors.Is(err, scraper.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// configureBreakers applies GOBER_BREAKER_THRESHOLD, the consecutive
+// failures that open a source's circuit, and GOBER_BREAKER_COOLDOWN, the wait
+// before probing it again.
+func configureBreakers(b *scraper.Breakers) error {
+	if v := os.Getenv("GOBER_BREAKER_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("GOBER_BREAKER_THRESHOLD must be a positive number")
+		}
+		b.Threshold = n
+	}
+	if v := os.Getenv("GOBER_BREAKER_COOLDOWN"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("GOBER_BREAKER_COOLDOWN must be a duration such as 30s")
+		}
+		b.Cooldown = d
+	}
+	return nil
+}
+
+// configureHTTPClient applies GOBER_HTTP_RETRIES (0 disables retries),
+// GOBER_HTTP_BUDGET, the most time one upstream fetch may take with retries,
+// and GOBER_HTTP_MAX_BODY_MB (0 lifts the limit).
+func configureHTTPClient(h *utils.RealHTTPClient) error {
+	if v := os.Getenv("GOBER_HTTP_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("GOBER_HTTP_RETRIES must be a non-negative number")
+		}
+		h.Retry.MaxRetries = n
 	}
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
-		return false
+	if v := os.Getenv("GOBER_HTTP_BUDGET"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("GOBER_HTTP_BUDGET must be a duration such as 20s")
+		}
+		h.Retry.Budget = d
+	}
+	if v := os.Getenv("GOBER_HTTP_MAX_BODY_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("GOBER_HTTP_MAX_BODY_MB must be a non-negative number")
+		}
+		h.MaxBodyBytes = int64(n) << 20
 	}
-	host := parsed.Hostname()
-	return host == "detik.com" || strings.HasSuffix(host, ".detik.com") ||
-		host == "kompas.com" || strings.HasSuffix(host, ".kompas.com")
+	return nil
+}
+
+// isAllowedURL rejects requests that don't target a registered news domain,
+// preventing SSRF via the detailUrl parameter.
+func isAllowedURL(rawURL string) bool {
+	return registry.IsAllowedURL(rawURL)
 }
 
 func serveStatic(c *gin.Context) {
@@ -134,7 +317,7 @@ func articleDetail(ginContext *gin.Context) {
 		return
 	}
 
-	scraper, err := getScraper(website)
+	scraper, err := getScraper(website, scraper.OpDetail)
 	if err != nil {
 		log.Printf("Error when getting scraper: %v", err)
 		ginContext.IndentedJSON(http.StatusUnprocessableEntity, gin.H{
@@ -144,10 +327,10 @@ func articleDetail(ginContext *gin.Context) {
 		return
 	}
 
-	article, err := scraper.Detail(detailUrl, ginContext)
+	article, err := scraper.Detail(ginContext.Request.Context(), detailUrl, utils.NewRequestLinkBuilder(ginContext.Request))
 	if err != nil {
 		log.Printf("Error scrap URL: %v", err)
-		ginContext.IndentedJSON(http.StatusInternalServerError, gin.H{
+		ginContext.IndentedJSON(scrapeErrorStatus(err), gin.H{
 			"desc":   err.Error(),
 			"status": "Failed",
 		})
@@ -171,7 +354,7 @@ func searchArticle(ginContext *gin.Context) {
 
 	log.Println("source:", website, "search key:", searchKey)
 
-	if searchKey == "" {
+	if searchKey == "" && website != archiveSource {
 		ginContext.IndentedJSON(http.StatusBadRequest, gin.H{
 			"desc":   "param q is not exists or is empty",
 			"status": "Failed",
@@ -179,7 +362,39 @@ func searchArticle(ginContext *gin.Context) {
 		return
 	}
 
-	scraper, err := getScraper(website)
+	query, err := parseListQuery(ginContext)
+	if err != nil {
+		ginContext.IndentedJSON(http.StatusBadRequest, gin.H{
+			"desc":   err.Error(),
+			"status": "Failed",
+		})
+		return
+	}
+
+	if website == archiveSource {
+		searchArchive(ginContext, searchKey, query)
+		return
+	}
+
+	ctx := ginContext.Request.Context()
+	links := utils.NewRequestLinkBuilder(ginContext.Request)
+
+	if scraper.IsMultiSource(website) {
+		names := registry.Names(website, scraper.OpSearch)
+		if len(names) == 0 {
+			ginContext.IndentedJSON(http.StatusBadRequest, gin.H{
+				"desc":   "param source does not name any source",
+				"status": "Failed",
+			})
+			return
+		}
+		respondAggregated(ginContext, func(page int) scraper.Aggregated {
+			return registry.SearchAll(ctx, names, searchKey, page, links)
+		}, true, query)
+		return
+	}
+
+	newsScraper, err := getScraper(website, scraper.OpSearch)
 	if err != nil {
 		log.Printf("Error when getting scraper: %v", err)
 		ginContext.IndentedJSON(http.StatusUnprocessableEntity, gin.H{
@@ -189,30 +404,51 @@ func searchArticle(ginContext *gin.Context) {
 		return
 	}
 
-	articles, err := scraper.Search(searchKey, ginContext)
+	page, err := scraper.Paginate(query.filtered(func(page int) ([]models.Article, bool, error) {
+		articles, err := newsScraper.Search(ctx, searchKey, page, links)
+		return articles, len(articles) > 0, err
+	}), query.cursor, query.limit)
 	if err != nil {
 		log.Printf("Error scrap URL: %v", err)
-		ginContext.IndentedJSON(http.StatusInternalServerError, gin.H{
+		ginContext.IndentedJSON(scrapeErrorStatus(err), gin.H{
 			"desc":   err.Error(),
 			"status": "Failed",
 		})
 		return
 	}
 
-	resp := GoberResp{
-		Status:   "Success",
-		Count:    len(articles),
-		Articles: articles,
-	}
-
-	ginContext.IndentedJSON(http.StatusOK, resp)
+	respondPage(ginContext, page, nil, query)
 }
 
 func getPopularArticle(ginContext *gin.Context) {
 	website := ginContext.DefaultQuery("source", "detik")
 	log.Println("source:", website)
 
-	scraper, err := getScraper(website)
+	query, err := parseListQuery(ginContext)
+	if err != nil {
+		ginContext.IndentedJSON(http.StatusBadRequest, gin.H{
+			"desc":   err.Error(),
+			"status": "Failed",
+		})
+		return
+	}
+
+	if scraper.IsMultiSource(website) {
+		names := registry.Names(website, scraper.OpPopular)
+		if len(names) == 0 {
+			ginContext.IndentedJSON(http.StatusBadRequest, gin.H{
+				"desc":   "param source does not name any source",
+				"status": "Failed",
+			})
+			return
+		}
+		respondAggregated(ginContext, func(page int) scraper.Aggregated {
+			return popularAll(ginContext, names)
+		}, false, query)
+		return
+	}
+
+	newsScraper, err := getScraper(website, scraper.OpPopular)
 	if err != nil {
 		log.Printf("Error when getting scraper: %v", err)
 		ginContext.IndentedJSON(http.StatusUnprocessableEntity, gin.H{
@@ -222,31 +458,196 @@ func getPopularArticle(ginContext *gin.Context) {
 		return
 	}
 
-	popArticles, err := scraper.Popular(ginContext)
+	popArticles, err := newsScraper.Popular(ginContext.Request.Context(), utils.NewRequestLinkBuilder(ginContext.Request))
 	if err != nil {
 		log.Printf("Error getting popular news: %v", err)
-		ginContext.IndentedJSON(http.StatusInternalServerError, gin.H{
+		ginContext.IndentedJSON(scrapeErrorStatus(err), gin.H{
 			"desc":   err.Error(),
 			"status": "Failed",
 		})
 		return
 	}
 
+	page, _ := scraper.Paginate(query.filtered(scraper.SinglePage(popArticles)), query.cursor, query.limit)
+	respondPage(ginContext, page, nil, query)
+}
+
+// popularAll returns the ranked multi-source popular feed, cached so every
+// client sees the same ordering. Partial results are not cached, letting a
+// failed source rejoin on the next request.
+func popularAll(ginContext *gin.Context, names []string) scraper.Aggregated {
+	cacheKey := "popular:" + strings.Join(names, ",")
+	// The cached feed is shared by every client, so it keeps relative links
+	// and is resolved per request, like the sources' own popular lists.
+	// The loader never fails; an error only means the client went away.
+	result, _ := utils.FetchAs(ginContext.Request.Context(), cache, cacheKey, func(ctx context.Context) (scraper.Aggregated, time.Duration, error) {
+		result := registry.PopularAll(ctx, names, utils.LinkBuilder{}, rankWeights)
+		if result.Complete() && len(result.Articles) > 0 {
+			return result, 5 * time.Minute, nil
+		}
+		return result, 0, nil
+	})
+	result.Articles = utils.NewRequestLinkBuilder(ginContext.Request).Resolve(result.Articles)
+	return result
+}
+
+var errAllSourcesFailed = errors.New("all sources failed")
+
+// respondAggregated paginates a multi-source result. fetch is called per
+// upstream page when paged, otherwise only once. The request only fails when
+// every source failed; otherwise per-source failures are reported in sources.
+func respondAggregated(ginContext *gin.Context, fetch func(page int) scraper.Aggregated, paged bool, query listQuery) {
+	var sources []scraper.SourceResult
+	page, err := scraper.Paginate(query.filtered(func(n int) ([]models.Article, bool, error) {
+		if !paged && n > 1 {
+			return nil, false, nil
+		}
+		result := fetch(n)
+		if sources == nil {
+			sources = result.Sources
+		}
+		if !result.Succeeded() {
+			return nil, false, errAllSourcesFailed
+		}
+		return result.Articles, paged && len(result.Articles) > 0, nil
+	}), query.cursor, query.limit)
+	if err != nil {
+		log.Printf("all sources failed: %+v", sources)
+		ginContext.IndentedJSON(http.StatusBadGateway, gin.H{
+			"desc":    err.Error(),
+			"status":  "Failed",
+			"sources": sources,
+		})
+		return
+	}
+
+	respondPage(ginContext, page, sources, query)
+}
+
+func respondPage(ginContext *gin.Context, page scraper.Page, sources []scraper.SourceResult, query listQuery) {
+	articles := page.Articles
+	if query.format != feed.None {
+		respondFeed(ginContext, articles, query.format)
+		return
+	}
+
 	resp := GoberResp{
 		Status:   "Success",
-		Count:    len(popArticles),
-		Articles: popArticles,
+		Count:    len(articles),
+		Articles: articles,
+		Sources:  sources,
+		HasMore:  page.HasMore(),
+	}
+	if page.Next != nil {
+		resp.NextCursor = page.Next.Encode()
 	}
 
 	ginContext.IndentedJSON(http.StatusOK, resp)
+}
+
+// archiveSource is the source param that searches the local archive instead
+// of a news site.
+const archiveSource = "archive"
 
+// archivePageSize is how many ranked archive hits make up one page for
+// scraper.Paginate.
+const archivePageSize = 20
+
+// searchArchive runs a full-text search over archived articles, or without
+// a search key lists them newest first. Either way in, since, until and title
+// narrow the results. It never touches the upstream sites, so it keeps
+// working while they're unreachable.
+func searchArchive(ginContext *gin.Context, searchKey string, query listQuery) {
+	if articleArchive == nil {
+		ginContext.IndentedJSON(http.StatusUnprocessableEntity, gin.H{
+			"desc":   "archive is disabled",
+			"status": "Failed",
+		})
+		return
+	}
+
+	ctx := ginContext.Request.Context()
+	links := utils.NewRequestLinkBuilder(ginContext.Request)
+	filter := archive.Filter{
+		Source: ginContext.Query("in"),
+		Since:  query.since,
+		Until:  query.until,
+		Title:  ginContext.Query("title"),
+		Limit:  archivePageSize,
+	}
+
+	page, err := scraper.Paginate(query.filtered(func(page int) ([]models.Article, bool, error) {
+		filter.Offset = (page - 1) * archivePageSize
+		var articles []models.Article
+		var err error
+		if searchKey == "" {
+			articles, err = articleArchive.Query(ctx, filter, links)
+		} else {
+			articles, err = articleArchive.Search(ctx, searchKey, filter, links)
+		}
+		return articles, len(articles) == archivePageSize, err
+	}), query.cursor, query.limit)
+	if err != nil {
+		log.Printf("Error searching archive: %v", err)
+		ginContext.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"desc":   err.Error(),
+			"status": "Failed",
+		})
+		return
+	}
+
+	respondPage(ginContext, page, nil, query)
 }
 
-func getScraper(website string) (scraper.NewsScraper, error) {
-	if website == "detik" {
-		return parsers.DetikScraper{Client: httpClient, Utils: scrapeUtils, Cache: cache}, nil
-	} else if website == "kompas" {
-		return parsers.KompasScraper{Client: httpClient, Utils: scrapeUtils, Cache: cache}, nil
+// respondFeed renders articles as an RSS, Atom or JSON Feed whose items link
+// to the web frontend's ad-free reader.
+func respondFeed(ginContext *gin.Context, articles []models.Article, format feed.Format) {
+	links := utils.NewRequestLinkBuilder(ginContext.Request)
+	website := ginContext.DefaultQuery("source", "detik")
+
+	title := "Gober - Terpopuler (" + website + ")"
+	if searchKey := ginContext.Query("q"); searchKey != "" {
+		title = "Gober - Pencarian \"" + searchKey + "\" (" + website + ")"
+	}
+
+	f := feed.FromArticles(feed.Feed{
+		Title:       title,
+		Description: "Berita tanpa iklan dari " + website + " via Gober",
+		Link:        links.BaseURL + "/?source=" + url.QueryEscape(website),
+		SelfLink:    links.BaseURL + ginContext.Request.URL.RequestURI(),
+	}, articles, links, website)
+
+	body, err := feed.Render(f, format)
+	if err != nil {
+		log.Printf("Error rendering %s feed: %v", format, err)
+		ginContext.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"desc":   err.Error(),
+			"status": "Failed",
+		})
+		return
+	}
+
+	ginContext.Data(http.StatusOK, format.ContentType(), body)
+}
+
+func listSources(ginContext *gin.Context) {
+	sources := registry.Sources()
+	ginContext.IndentedJSON(http.StatusOK, gin.H{
+		"status":  "Success",
+		"count":   len(sources),
+		"sources": sources,
+	})
+}
+
+// getScraper resolves the scraper registered for website and makes sure it
+// supports the requested operation.
+func getScraper(website string, op scraper.Operation) (scraper.NewsScraper, error) {
+	src, ok := registry.Source(website)
+	if !ok {
+		return nil, fmt.Errorf("scrape %v not supported", website)
+	}
+	if !src.Capabilities.Supports(op) {
+		return nil, fmt.Errorf("%v is not supported for source %v", op, website)
 	}
-	return nil, fmt.Errorf("scrape %v not supported", website)
+	return src.Scraper, nil
 }
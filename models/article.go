@@ -1,12 +1,20 @@
 package models
 
+import "time"
+
 type Article struct {
+	// ID identifies the article within its source however it was linked to
+	// (see package canonical).
+	ID        string `json:"id,omitempty"`
 	URL       string `json:"url"`
 	Title     string `json:"title"`
 	ShortDesc string `json:"description"`
 	Author    string `json:"author"`
 	Date      string `json:"timestamp"`
-	SourceUrl string `json:"source_url"`
-	Content   string `json:"content"`
-	ImgUrl    string `json:"img_url"`
+	// PublishedAt is Date parsed into an absolute time; nil when Date is unparseable.
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	SourceUrl   string     `json:"source_url"`
+	Content     string     `json:"content"`
+	ImgUrl      string     `json:"img_url"`
+	Source      string     `json:"source,omitempty"`
 }
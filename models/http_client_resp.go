@@ -4,4 +4,7 @@ type ScraperResponse struct {
 	Body   string
 	Status int
 	WebUrl string
+	// NotModified is set when the server answered 304 and Body is the copy
+	// kept from an earlier fetch.
+	NotModified bool
 }
@@ -0,0 +1,181 @@
+package parsers
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// cnnArticleTime matches the yyyyMMddHHmmss prefix of the id segment in
+// CNN Indonesia article paths, e.g. /nasional/20241202101530-12-1172345/slug.
+var cnnArticleTime = regexp.MustCompile(`/(\d{14})-\d+-\d+/`)
+
+// cnnLocation is the zone CNN Indonesia publishes its timestamps in (WIB).
+var cnnLocation = time.FixedZone("WIB", 7*60*60)
+
+type CNNIndonesiaScraper struct {
+	Client utils.HTTPClient
+	Utils  utils.ScrapeUtils
+	Cache  utils.CacheOps
+}
+
+func (cnn CNNIndonesiaScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	searchUrl := "https://www.cnnindonesia.com/search?query=" + url.QueryEscape(keyword)
+	if page > 1 {
+		searchUrl += "&page=" + strconv.Itoa(page)
+	}
+	resp, err := cnn.Client.Get(ctx, searchUrl)
+	if err != nil {
+		return []models.Article{}, err
+	}
+
+	if resp.Status != 200 {
+		return []models.Article{}, utils.StatusError{Code: resp.Status}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
+	if err != nil {
+		return []models.Article{}, err
+	}
+
+	return fetchArticlesCNNIndonesia(doc, links), nil
+}
+
+func (cnn CNNIndonesiaScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	result, err := utils.FetchAs(ctx, cnn.Cache, "ccnid:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		popUrls := []string{
+			"https://www.cnnindonesia.com/nasional/terpopuler",
+			"https://www.cnnindonesia.com/internasional/terpopuler",
+			"https://www.cnnindonesia.com/ekonomi/terpopuler",
+			"https://www.cnnindonesia.com/olahraga/terpopuler",
+			"https://www.cnnindonesia.com/teknologi/terpopuler",
+			"https://www.cnnindonesia.com/hiburan/terpopuler",
+			"https://www.cnnindonesia.com/gaya-hidup/terpopuler",
+		}
+
+		result := cnn.Utils.FetchListArticles(ctx, fetchArticlesCNNIndonesia, popUrls, utils.LinkBuilder{})
+
+		log.Printf("CNN Indonesia articles: %v", len(result))
+		if len(result) == 0 {
+			return result, 0, nil
+		}
+		return result, 5 * time.Minute, nil
+	})
+	return links.Resolve(result), err
+}
+
+// Latest lists the newest articles from CNN Indonesia's index page. Unlike
+// Popular it isn't cached: it exists for the crawler to find new articles.
+func (cnn CNNIndonesiaScraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexUrls := []string{
+		"https://www.cnnindonesia.com/indeks",
+	}
+
+	result := cnn.Utils.FetchListArticles(ctx, fetchArticlesCNNIndonesia, indexUrls, links)
+	log.Printf("CNN Indonesia latest articles: %v", len(result))
+	return result, nil
+}
+
+func (cnn CNNIndonesiaScraper) Detail(ctx context.Context, detailUrl string, links utils.LinkBuilder) (models.Article, error) {
+	return utils.FetchAs(ctx, cnn.Cache, "ccnid:"+canonical.ID(detailUrl), func(ctx context.Context) (models.Article, time.Duration, error) {
+		article, err := cnn.fetchDetail(ctx, detailUrl)
+		return article, 5 * time.Minute, err
+	})
+}
+
+func (cnn CNNIndonesiaScraper) fetchDetail(ctx context.Context, detailUrl string) (models.Article, error) {
+	resp, err := cnn.Client.Get(ctx, detailUrl)
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	if resp.Status != 200 {
+		return models.Article{}, utils.StatusError{Code: resp.Status}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	article := models.Article{}
+	article.URL = detailUrl
+	article.ID = canonical.ID(detailUrl)
+	article.Title = strings.TrimSpace(doc.Find("h1").First().Text())
+	article.Author = doc.Find(`meta[name="author"]`).AttrOr("content", "")
+	article.ImgUrl = doc.Find(`meta[property="og:image"]`).AttrOr("content", "")
+
+	publishDate := doc.Find(`meta[name="publishdate"]`).AttrOr("content", "")
+	if published, err := time.ParseInLocation("2006/01/02 15:04:05", publishDate, cnnLocation); err == nil {
+		article.Date = formatCNNIndonesiaDate(published)
+		article.PublishedAt = &published
+	} else {
+		article.Date = publishDate
+	}
+
+	content := utils.MainContent(doc, doc.Find("div.detail-text"), detailUrl)
+	utils.RewriteContentLinks(content)
+	article.Content = utils.CleanContent(content,
+		".adv-detail",
+		".inline_ad",
+		".parallaxindetail",
+		`[id^="div-gpt-ad"]`,
+		".para_caption",
+	)
+
+	return article, nil
+}
+
+func fetchArticlesCNNIndonesia(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
+	var listArticles []models.Article
+	doc.Find("article").Each(func(i int, s *goquery.Selection) {
+		anchor := s.Find("a[href]").First()
+		resultUrl, _ := anchor.Attr("href")
+		title := strings.TrimSpace(s.Find("h2").First().Text())
+		if resultUrl == "" || title == "" {
+			return
+		}
+
+		article := models.Article{}
+		article.URL = links.Article("ccnid", resultUrl)
+		article.SourceUrl = resultUrl
+		article.ID = canonical.ID(article.SourceUrl)
+		article.Title = title
+
+		parsedUrl, err := url.Parse(resultUrl)
+		if err == nil {
+			article.ShortDesc = parsedUrl.Host
+		}
+
+		img, imgExists := s.Find("img").Attr("src")
+		if imgExists {
+			article.ImgUrl = utils.EnhanceImageURL(img)
+		}
+
+		//extract date from cnn indonesia url
+		if m := cnnArticleTime.FindStringSubmatch(resultUrl); m != nil {
+			if published, err := time.ParseInLocation("20060102150405", m[1], cnnLocation); err == nil {
+				article.Date = formatCNNIndonesiaDate(published)
+				article.PublishedAt = &published
+			}
+		} else {
+			log.Printf("article time from url %s is unparseable", resultUrl)
+		}
+
+		listArticles = append(listArticles, article)
+	})
+	return listArticles
+}
+
+func formatCNNIndonesiaDate(t time.Time) string {
+	return t.Format("02/01/2006, 15:04") + " WIB"
+}
@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -8,9 +9,9 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/canonical"
 	"github.com/akhmadreiza/gober/models"
 	"github.com/akhmadreiza/gober/utils"
-	"github.com/gin-gonic/gin"
 )
 
 type DetikScraper struct {
@@ -19,20 +20,24 @@ type DetikScraper struct {
 	Cache  utils.CacheOps
 }
 
-func (detik DetikScraper) Detail(detailUrl string, c *gin.Context) (models.Article, error) {
-	if cachedData, found := detik.Cache.Get("detik:" + detailUrl); found {
-		if article, ok := cachedData.(models.Article); ok {
-			return article, nil
-		}
-	}
+func (detik DetikScraper) Detail(ctx context.Context, detailUrl string, links utils.LinkBuilder) (models.Article, error) {
+	// Every variant shares the canonical cache key, so always fetch the
+	// whole article rather than its first page.
+	detailUrl = utils.WithQueryParam(detailUrl, "single", "1")
+	return utils.FetchAs(ctx, detik.Cache, "detik:"+canonical.ID(detailUrl), func(ctx context.Context) (models.Article, time.Duration, error) {
+		article, err := detik.fetchDetail(ctx, detailUrl)
+		return article, 5 * time.Minute, err
+	})
+}
 
-	resp, err := detik.Client.Get(detailUrl)
+func (detik DetikScraper) fetchDetail(ctx context.Context, detailUrl string) (models.Article, error) {
+	resp, err := detik.Client.Get(ctx, detailUrl)
 	if err != nil {
 		return models.Article{}, err
 	}
 
 	if resp.Status != 200 {
-		return models.Article{}, fmt.Errorf("error: status code %d", resp.Status)
+		return models.Article{}, utils.StatusError{Code: resp.Status}
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
@@ -47,15 +52,18 @@ func (detik DetikScraper) Detail(detailUrl string, c *gin.Context) (models.Artic
 
 	article := models.Article{}
 	article.URL = detailUrl
+	article.ID = canonical.ID(detailUrl)
 	article.Title = title
 	article.Author = author
 	article.Date = articleDate
+	article.PublishedAt = utils.PublishedAt(articleDate)
 	article.ImgUrl = imageUrl
 
 	content := doc.Find("div.detail__body-text.itp_bodycontent")
 	if content.Length() == 0 {
 		content = doc.Find("div.detail__body-text")
 	}
+	content = utils.MainContent(doc, content, detailUrl)
 	utils.RewriteContentLinks(content)
 	article.Content = utils.CleanContent(content,
 		".paradetail",
@@ -66,19 +74,18 @@ func (detik DetikScraper) Detail(detailUrl string, c *gin.Context) (models.Artic
 		".aevp",
 	)
 
-	detik.Cache.Set("detik:"+article.URL, article, 5*time.Minute)
 	return article, nil
 }
 
-func (detik DetikScraper) Search(keyword string, ginContext *gin.Context) ([]models.Article, error) {
-	searchUrl := fmt.Sprintf("https://www.detik.com/search/searchall?query=%v&page=1&result_type=latest", keyword)
-	resp, err := detik.Client.Get(searchUrl)
+func (detik DetikScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	searchUrl := fmt.Sprintf("https://www.detik.com/search/searchall?query=%v&page=%d&result_type=latest", url.QueryEscape(keyword), page)
+	resp, err := detik.Client.Get(ctx, searchUrl)
 	if err != nil {
 		return []models.Article{}, err
 	}
 
 	if resp.Status != 200 {
-		return []models.Article{}, fmt.Errorf("error: status code %d", resp.Status)
+		return []models.Article{}, utils.StatusError{Code: resp.Status}
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
@@ -86,42 +93,51 @@ func (detik DetikScraper) Search(keyword string, ginContext *gin.Context) ([]mod
 		return []models.Article{}, err
 	}
 
-	return fetchArticlesDetik(doc, ginContext), nil
+	return fetchArticlesDetik(doc, links), nil
 }
 
-func (detik DetikScraper) Popular(ginContext *gin.Context) ([]models.Article, error) {
-	if cachedData, found := detik.Cache.Get("detik:popular"); found {
-		if articles, ok := cachedData.([]models.Article); ok {
-			log.Print("cache detik:popular found. return data from cache.")
-			return articles, nil
+func (detik DetikScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	result, err := utils.FetchAs(ctx, detik.Cache, "detik:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		popUrls := []string{
+			"https://www.detik.com/terpopuler/news",
+			"https://www.detik.com/terpopuler/finance",
+			"https://www.detik.com/terpopuler/hot",
+			"https://www.detik.com/terpopuler/inet",
+			"https://www.detik.com/terpopuler/sport",
+			"https://www.detik.com/terpopuler/oto",
+			"https://www.detik.com/terpopuler/travel",
+			"https://www.detik.com/terpopuler/sepakbola",
+			"https://www.detik.com/terpopuler/food",
+			"https://www.detik.com/terpopuler/health",
+			"https://www.detik.com/terpopuler/edu",
 		}
-	}
 
-	popUrls := []string{
-		"https://www.detik.com/terpopuler/news",
-		"https://www.detik.com/terpopuler/finance",
-		"https://www.detik.com/terpopuler/hot",
-		"https://www.detik.com/terpopuler/inet",
-		"https://www.detik.com/terpopuler/sport",
-		"https://www.detik.com/terpopuler/oto",
-		"https://www.detik.com/terpopuler/travel",
-		"https://www.detik.com/terpopuler/sepakbola",
-		"https://www.detik.com/terpopuler/food",
-		"https://www.detik.com/terpopuler/health",
-		"https://www.detik.com/terpopuler/edu",
-	}
+		result := detik.Utils.FetchListArticles(ctx, fetchArticlesDetik, popUrls, utils.LinkBuilder{})
 
-	result := detik.Utils.FetchListArticles(fetchArticlesDetik, popUrls, ginContext)
+		log.Printf("Detik articles: %v", len(result))
+		if len(result) == 0 {
+			return result, 0, nil
+		}
+		return result, 5 * time.Minute, nil
+	})
+	return links.Resolve(result), err
+}
 
-	log.Printf("Detik articles: %v", len(result))
-	if len(result) > 0 {
-		detik.Cache.Set("detik:popular", result, 5*time.Minute)
+// Latest lists the newest articles from detik.com's index page. Unlike
+// Popular it isn't cached: it exists for the crawler to find new articles.
+func (detik DetikScraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexUrls := []string{
+		"https://news.detik.com/indeks",
+		"https://finance.detik.com/indeks",
+		"https://sport.detik.com/indeks",
 	}
 
+	result := detik.Utils.FetchListArticles(ctx, fetchArticlesDetik, indexUrls, links)
+	log.Printf("Detik latest articles: %v", len(result))
 	return result, nil
 }
 
-func fetchArticlesDetik(doc *goquery.Document, c *gin.Context) []models.Article {
+func fetchArticlesDetik(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
 	var listArticles []models.Article
 	doc.Find("article.list-content__item").Each(func(i int, s *goquery.Selection) {
 		article := models.Article{}
@@ -136,12 +152,9 @@ func fetchArticlesDetik(doc *goquery.Document, c *gin.Context) []models.Article
 
 		articleTitle := media.Find("a").Text()
 
-		scheme := "http"
-		if c.Request.TLS != nil {
-			scheme = "https"
-		}
-		article.URL = scheme + "://" + c.Request.Host + "/article?source=detik&detailUrl=" + url.QueryEscape(resultUrl+"?single=1")
+		article.URL = links.Article("detik", resultUrl+"?single=1")
 		article.SourceUrl = resultUrl + "?single=1"
+		article.ID = canonical.ID(article.SourceUrl)
 		article.Title = articleTitle
 		if imgExists {
 			article.ImgUrl = utils.EnhanceImageURL(img)
@@ -153,6 +166,7 @@ func fetchArticlesDetik(doc *goquery.Document, c *gin.Context) []models.Article
 		}
 
 		article.Date = s.Find("div.media__date").Find("span").AttrOr("title", "-")
+		article.PublishedAt = utils.PublishedAt(article.Date)
 
 		listArticles = append(listArticles, article)
 	})
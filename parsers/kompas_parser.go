@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -8,9 +9,9 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/canonical"
 	"github.com/akhmadreiza/gober/models"
 	"github.com/akhmadreiza/gober/utils"
-	"github.com/gin-gonic/gin"
 )
 
 type KompasScraper struct {
@@ -19,50 +20,64 @@ type KompasScraper struct {
 	Cache  utils.CacheOps
 }
 
-func (k KompasScraper) Search(keyword string, g *gin.Context) ([]models.Article, error) {
-	return []models.Article{}, fmt.Errorf("KompasScraper Search is not supported")
-}
-
-func (k KompasScraper) Popular(c *gin.Context) ([]models.Article, error) {
-	if cachedData, found := k.Cache.Get("kompas:popular"); found {
-		if articles, ok := cachedData.([]models.Article); ok {
-			log.Print("cache kompas:popular found. return data from cache.")
-			return articles, nil
-		}
+func (k KompasScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	searchUrl := fmt.Sprintf("https://search.kompas.com/search?q=%v&page=%d", url.QueryEscape(keyword), page)
+	resp, err := k.Client.Get(ctx, searchUrl)
+	if err != nil {
+		return []models.Article{}, err
 	}
 
-	popUrls := []string{
-		"https://indeks.kompas.com/headline",
-		"https://indeks.kompas.com/headline?page=2",
-		"https://indeks.kompas.com/headline?page=3",
-		"https://indeks.kompas.com/terpopuler",
-		"https://indeks.kompas.com/terpopuler?page=2",
+	if resp.Status != 200 {
+		return []models.Article{}, utils.StatusError{Code: resp.Status}
 	}
 
-	result := k.Utils.FetchListArticles(fetchArticlesKompas, popUrls, c)
-
-	log.Printf("Kompas articles: %v", len(result))
-	if len(result) > 0 {
-		k.Cache.Set("kompas:popular", result, 5*time.Minute)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
+	if err != nil {
+		return []models.Article{}, err
 	}
 
-	return result, nil
+	return fetchArticlesKompas(doc, links), nil
 }
 
-func (k KompasScraper) Detail(url string, c *gin.Context) (models.Article, error) {
-	if cachedData, found := k.Cache.Get("kompas:" + url); found {
-		if article, ok := cachedData.(models.Article); ok {
-			return article, nil
+func (k KompasScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	result, err := utils.FetchAs(ctx, k.Cache, "kompas:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		popUrls := []string{
+			"https://indeks.kompas.com/headline",
+			"https://indeks.kompas.com/headline?page=2",
+			"https://indeks.kompas.com/headline?page=3",
+			"https://indeks.kompas.com/terpopuler",
+			"https://indeks.kompas.com/terpopuler?page=2",
 		}
-	}
 
-	resp, err := k.Client.Get(url)
+		result := k.Utils.FetchListArticles(ctx, fetchArticlesKompas, popUrls, utils.LinkBuilder{})
+
+		log.Printf("Kompas articles: %v", len(result))
+		if len(result) == 0 {
+			return result, 0, nil
+		}
+		return result, 5 * time.Minute, nil
+	})
+	return links.Resolve(result), err
+}
+
+func (k KompasScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	// Every variant shares the canonical cache key, so always fetch the
+	// whole article rather than its first page.
+	url = utils.WithQueryParam(url, "page", "all")
+	return utils.FetchAs(ctx, k.Cache, "kompas:"+canonical.ID(url), func(ctx context.Context) (models.Article, time.Duration, error) {
+		article, err := k.fetchDetail(ctx, url)
+		return article, 5 * time.Minute, err
+	})
+}
+
+func (k KompasScraper) fetchDetail(ctx context.Context, url string) (models.Article, error) {
+	resp, err := k.Client.Get(ctx, url)
 	if err != nil {
 		return models.Article{}, err
 	}
 
 	if resp.Status != 200 {
-		return models.Article{}, fmt.Errorf("error: status code %d", resp.Status)
+		return models.Article{}, utils.StatusError{Code: resp.Status}
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
@@ -77,38 +92,48 @@ func (k KompasScraper) Detail(url string, c *gin.Context) (models.Article, error
 
 	article := models.Article{}
 	article.URL = url
+	article.ID = canonical.ID(url)
 	article.Title = title
 	article.Author = author
 	article.Date = articleDate
+	article.PublishedAt = utils.PublishedAt(articleDate)
 	article.ImgUrl = imageUrl
 
-	readContent := doc.Find("div.read__content")
+	readContent := utils.MainContent(doc, doc.Find("div.read__content"), url)
 	utils.RewriteContentLinks(readContent)
 	article.Content = utils.CleanContent(readContent,
 		".kompasidRec",
 	)
 
-	k.Cache.Set("kompas:"+article.URL, article, 5*time.Minute)
 	return article, nil
 }
 
-func fetchArticlesKompas(doc *goquery.Document, c *gin.Context) []models.Article {
+// Latest lists the newest articles from kompas.com's index page. Unlike
+// Popular it isn't cached: it exists for the crawler to find new articles.
+func (k KompasScraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexUrls := []string{
+		"https://indeks.kompas.com/",
+		"https://indeks.kompas.com/?page=2",
+	}
+
+	result := k.Utils.FetchListArticles(ctx, fetchArticlesKompas, indexUrls, links)
+	log.Printf("Kompas latest articles: %v", len(result))
+	return result, nil
+}
+
+func fetchArticlesKompas(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
 	var listArticles []models.Article
 	doc.Find("div.articleItem").Each(func(i int, s *goquery.Selection) {
 		article := models.Article{}
 
-		var resultUrl string
-		articleUrl, attrExists := s.Find("a.article-link").Attr("href")
-		if attrExists {
-			resultUrl = articleUrl
+		resultUrl, attrExists := s.Find("a.article-link").Attr("href")
+		if !attrExists {
+			return
 		}
 		article.SourceUrl = resultUrl + "?page=all"
+		article.ID = canonical.ID(article.SourceUrl)
 
-		scheme := "http"
-		if c.Request.TLS != nil {
-			scheme = "https"
-		}
-		article.URL = scheme + "://" + c.Request.Host + "/article?source=kompas&detailUrl=" + url.QueryEscape(resultUrl)
+		article.URL = links.Article("kompas", resultUrl)
 
 		parsedUrl, err := url.Parse(resultUrl + "?page=all")
 		if err == nil {
@@ -116,11 +141,16 @@ func fetchArticlesKompas(doc *goquery.Document, c *gin.Context) []models.Article
 
 			//extract date from kompas url
 			su := strings.Split(parsedUrl.Path, "/")
+			if len(su) < 2 {
+				log.Printf("kompas article url %s is too short, skipping it", resultUrl)
+				return
+			}
 			at := su[len(su)-2]
 			if len(at) >= 4 {
 				ah := at[0:2]
 				am := at[2:4]
 				article.Date = s.Find("div.articlePost-date").Text() + ", " + ah + ":" + am + " WIB"
+				article.PublishedAt = utils.PublishedAt(article.Date)
 			} else {
 				log.Printf("article time from url %s is unparseable", resultUrl)
 			}
@@ -1,18 +1,17 @@
 package parsers_test
 
 import (
-	"net/http/httptest"
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/akhmadreiza/gober/models"
 	"github.com/akhmadreiza/gober/parsers"
 	"github.com/akhmadreiza/gober/utils"
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
-var ginContext = createTestGinContext()
-
 func TestDetailDetik(t *testing.T) {
 
 	//prepare data
@@ -37,17 +36,44 @@ func TestDetailDetik(t *testing.T) {
 	util := utils.NewScrapeUtils(mockClient)
 	cache := utils.NewCache()
 	scraper := parsers.DetikScraper{Client: mockClient, Utils: util, Cache: cache}
-	result, err := scraper.Detail("https://detik.com", ginContext)
+	result, err := scraper.Detail(context.Background(), "https://detik.com", utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
 	assert.Equal(t, "Test Title", result.Title)
 	assert.Equal(t, "Test Author", result.Author)
 	assert.Equal(t, "2024-11-29", result.Date)
+	assert.Nil(t, result.PublishedAt)
 	assert.Equal(t, "https://example.com/image.jpg", result.ImgUrl)
 	assert.Equal(t, "Test Content", result.Content)
 }
 
+func TestDetailDetikFetchesFullArticleForEveryVariant(t *testing.T) {
+	//prepare data
+	page := func(content string) models.ScraperResponse {
+		return models.ScraperResponse{Status: 200, Body: `<html><h1 class="detail__title">Judul</h1><div class="detail__body-text itp_bodycontent">` + content + `</div></html>`}
+	}
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: page("Halaman pertama"),
+		Responses: map[string]models.ScraperResponse{
+			"https://news.detik.com/berita/d-7666179/banjir?single=1": page("Artikel lengkap"),
+		},
+	}
+
+	//do test
+	scraper := parsers.DetikScraper{Client: mockClient, Utils: utils.NewScrapeUtils(mockClient), Cache: utils.NewCache()}
+	paged, err := scraper.Detail(context.Background(), "https://news.detik.com/berita/d-7666179/banjir", utils.LinkBuilder{})
+	assert.NoError(t, err)
+	full, err := scraper.Detail(context.Background(), "https://news.detik.com/berita/d-7666179/banjir?single=1", utils.LinkBuilder{})
+	assert.NoError(t, err)
+
+	//assertions
+	assert.Equal(t, "Artikel lengkap", paged.Content)
+	assert.Equal(t, "Artikel lengkap", full.Content)
+}
+
 func TestSearchDetik(t *testing.T) {
 	//prepare data
 	mockHTML := `
@@ -100,14 +126,16 @@ func TestSearchDetik(t *testing.T) {
 	util := utils.NewScrapeUtils(mockClient)
 	cache := utils.NewCache()
 	scraper := parsers.DetikScraper{Client: mockClient, Utils: util, Cache: cache}
-	result, err := scraper.Search("anak abah", ginContext)
+	result, err := scraper.Search(context.Background(), "anak abah", 1, utils.LinkBuilder{BaseURL: "https://gober.example"})
 
 	//assertions
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "https://gober.example/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fberita%2Fd-7666179%2Fpolisi-tindak-wisatawan-pakai-pelat-palsu-polri-demi-lolos-gage-di-puncak%3Fsingle%3D1", result[0].URL)
 	assert.Equal(t, "Polisi Tindak Wisatawan Pakai Pelat Palsu Polri Demi Lolos Gage di Puncak", result[0].Title)
 	assert.Equal(t, "", result[0].Author)
 	assert.Equal(t, "Minggu, 01 Des 2024 23:30 WIB", result[0].Date)
+	assert.Equal(t, "2024-12-01T23:30:00+07:00", result[0].PublishedAt.Format(time.RFC3339))
 	assert.Equal(t, "https://akcdn.detik.net.id/community/media/visual/2024/12/01/wisatawan-pakai-pelat-palsu-polri-di-puncak_43.jpeg?w=800&q=90", result[0].ImgUrl)
 }
 
@@ -134,7 +162,7 @@ func TestPopularDetikNoResult(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.DetikScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -186,7 +214,7 @@ func TestPopularDetikNoCache(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.DetikScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -244,7 +272,7 @@ func TestPopularDetikWithCache(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.DetikScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -257,7 +285,28 @@ func TestPopularDetikWithCache(t *testing.T) {
 	assert.Equal(t, 1, len(resdata))
 }
 
-func TestSearchKompas(t *testing.T) {
+func TestPopularDetikResolvesCachedLinks(t *testing.T) {
+	//prepare data
+	cached := []models.Article{{URL: "/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fd-1"}}
+
+	//mock
+	mockClient := utils.HttpClientMock{}
+	cache := utils.CacheMock{
+		Items: utils.CacheItemsMock{Data: cached, Found: true},
+	}
+
+	//do test
+	scraper := parsers.DetikScraper{Client: mockClient, Utils: utils.NewScrapeUtils(mockClient), Cache: &cache}
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{BaseURL: "https://gober.example"})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gober.example/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fd-1", res[0].URL)
+	// the shared cache entry keeps its relative link
+	assert.Equal(t, "/article?source=detik&detailUrl=https%3A%2F%2Fnews.detik.com%2Fd-1", cached[0].URL)
+}
+
+func TestSearchKompasNoResult(t *testing.T) {
 	//prepare data
 	mockHTML := `<html></html>`
 
@@ -273,10 +322,120 @@ func TestSearchKompas(t *testing.T) {
 	util := utils.NewScrapeUtils(mockClient)
 	cache := utils.NewCache()
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: cache}
-	_, err := scraper.Search("anak abah", ginContext)
+	result, err := scraper.Search(context.Background(), "anak abah", 1, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(result))
+}
+
+func TestSearchKompasPages(t *testing.T) {
+	//prepare data
+	page1 := `
+	<html>
+		<div class="articleList -list">
+			<div class="articleItem">
+				<a class="article-link" href="https://nasional.kompas.com/read/2025/01/02/10150001/anak-abah-kumpul">
+					<div class="articleItem-img"><img src="https://asset.kompas.com/crops/abc=/0x0:0x0/230x153/data/photo/2025/01/02/a.jpg" alt=""></div>
+					<h2 class="articleTitle">Anak Abah Kumpul</h2>
+					<div class="articlePost-date">02/01/2025</div>
+				</a>
+			</div>
+			<div class="articleItem">
+				<a class="article-link" href="https://megapolitan.kompas.com/read/2025/01/02/09000031/anak-abah-bertemu">
+					<h2 class="articleTitle">Anak Abah Bertemu</h2>
+					<div class="articlePost-date">02/01/2025</div>
+				</a>
+			</div>
+		</div>
+	</html>`
+	page2 := `
+	<html>
+		<div class="articleItem">
+			<a class="article-link" href="https://nasional.kompas.com/read/2025/01/01/20300011/anak-abah-lagi">
+				<h2 class="articleTitle">Anak Abah Lagi</h2>
+				<div class="articlePost-date">01/01/2025</div>
+			</a>
+		</div>
+	</html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Responses: map[string]models.ScraperResponse{
+			"https://search.kompas.com/search?q=anak+abah&page=1": {Body: page1, Status: 200},
+			"https://search.kompas.com/search?q=anak+abah&page=2": {Body: page2, Status: 200},
+			"https://search.kompas.com/search?q=anak+abah&page=3": {Body: `<html></html>`, Status: 200},
+		},
+		Response: models.ScraperResponse{Status: 500},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Search(context.Background(), "anak abah", 1, utils.LinkBuilder{})
+	nextPage, nextErr := scraper.Search(context.Background(), "anak abah", 2, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "Anak Abah Kumpul", result[0].Title)
+	assert.Equal(t, "02/01/2025, 10:15 WIB", result[0].Date)
+	assert.Equal(t, "2025-01-02T10:15:00+07:00", result[0].PublishedAt.Format(time.RFC3339))
+	assert.Equal(t, "nasional.kompas.com", result[0].ShortDesc)
+	assert.Equal(t, "https://nasional.kompas.com/read/2025/01/02/10150001/anak-abah-kumpul?page=all", result[0].SourceUrl)
+	assert.Equal(t, "https://asset.kompas.com/crops/abc=/0x0:0x0/460x306/data/photo/2025/01/02/a.jpg", result[0].ImgUrl)
+	assert.NoError(t, nextErr)
+	assert.Equal(t, 1, len(nextPage))
+	assert.Equal(t, "Anak Abah Lagi", nextPage[0].Title)
+}
+
+func TestSearchKompasSkipsMalformedItems(t *testing.T) {
+	//prepare data
+	mockHTML := `
+	<html>
+		<div class="articleItem">
+			<h2 class="articleTitle">Tanpa Tautan</h2>
+		</div>
+		<div class="articleItem">
+			<a class="article-link" href="https://www.kompas.com"><h2 class="articleTitle">Beranda</h2></a>
+		</div>
+		<div class="articleItem">
+			<a class="article-link" href="https://nasional.kompas.com/read/2025/01/02/10150001/anak-abah-kumpul">
+				<h2 class="articleTitle">Anak Abah Kumpul</h2>
+				<div class="articlePost-date">02/01/2025</div>
+			</a>
+		</div>
+	</html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{Body: mockHTML, Status: 200},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Search(context.Background(), "anak abah", 1, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "Anak Abah Kumpul", result[0].Title)
+}
+
+func TestSearchKompasNon200(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{Status: 503},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	_, err := scraper.Search(context.Background(), "anak abah", 1, utils.LinkBuilder{})
 
 	//assertions
-	assert.EqualError(t, err, "KompasScraper Search is not supported")
+	assert.EqualError(t, err, "error: status code 503")
 }
 
 func TestDetailKompas(t *testing.T) {
@@ -303,7 +462,7 @@ func TestDetailKompas(t *testing.T) {
 	util := utils.NewScrapeUtils(mockClient)
 	cache := utils.NewCache()
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: cache}
-	result, err := scraper.Detail("https://kompas.com", ginContext)
+	result, err := scraper.Detail(context.Background(), "https://kompas.com", utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -314,6 +473,68 @@ func TestDetailKompas(t *testing.T) {
 	assert.Equal(t, "Test Content", result.Content)
 }
 
+func TestDetailKompasFetchesFullArticleForEveryVariant(t *testing.T) {
+	//prepare data
+	page := func(content string) models.ScraperResponse {
+		return models.ScraperResponse{Status: 200, Body: `<html><h1 class="read__title">Judul</h1><div class="read__content">` + content + `</div></html>`}
+	}
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: page("Halaman pertama"),
+		Responses: map[string]models.ScraperResponse{
+			"https://www.kompas.com/read/2024/12/01/1?page=all": page("Artikel lengkap"),
+		},
+	}
+
+	//do test
+	scraper := parsers.KompasScraper{Client: mockClient, Utils: utils.NewScrapeUtils(mockClient), Cache: utils.NewCache()}
+	paged, err := scraper.Detail(context.Background(), "https://www.kompas.com/read/2024/12/01/1", utils.LinkBuilder{})
+	assert.NoError(t, err)
+	full, err := scraper.Detail(context.Background(), "https://www.kompas.com/read/2024/12/01/1?page=all", utils.LinkBuilder{})
+	assert.NoError(t, err)
+
+	//assertions
+	assert.Equal(t, "Artikel lengkap", paged.Content)
+	assert.Equal(t, "Artikel lengkap", full.Content)
+}
+
+func TestDetailKompasFallsBackToExtractedContent(t *testing.T) {
+	//prepare data
+	mockHTML := `
+	<html>
+		<h1 class="read__title">Test Title</h1>
+		<div class="article-body-2025">
+			<p>Paragraf pertama berita, dengan cukup banyak teks, koma, dan detail peristiwa.</p>
+			<p>Paragraf kedua menjelaskan latar belakang, tanggapan pejabat, serta dampaknya.</p>
+		</div>
+		<div class="related">
+			<p><a href="https://www.kompas.com/read/2024/05/01/1/lain">Berita terkait yang judulnya cukup panjang</a></p>
+		</div>
+	</html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   mockHTML,
+			Status: 200,
+		},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	cache := utils.NewCache()
+	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: cache}
+	result, err := scraper.Detail(context.Background(), "https://kompas.com", utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Title", result.Title)
+	assert.Contains(t, result.Content, "Paragraf pertama")
+	assert.Contains(t, result.Content, "Paragraf kedua")
+	assert.NotContains(t, result.Content, "Berita terkait")
+}
+
 func TestPopularKompasNoResult(t *testing.T) {
 	//prepare data
 	mockHTML := `
@@ -337,7 +558,7 @@ func TestPopularKompasNoResult(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -396,17 +617,17 @@ func TestPopularKompasNoCache(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
-	assert.Equal(t, 2, len(res)) //2 is sum of popUrls slice in kompas_parser
+	assert.Equal(t, 5, len(res)) //5 is sum of popUrls slice in kompas_parser
 
 	//make sure data inserted to cache
 	resdata, ok := cache.Items.Data.([]models.Article)
 	assert.True(t, ok)
 	assert.NotNil(t, resdata)
-	assert.Equal(t, 2, len(resdata))
+	assert.Equal(t, 5, len(resdata))
 }
 
 func TestPopularKompasWithCache(t *testing.T) {
@@ -461,7 +682,7 @@ func TestPopularKompasWithCache(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
@@ -522,28 +743,368 @@ func TestPopularKompasNoArticleTimeFromURL(t *testing.T) {
 	//do test
 	util := utils.NewScrapeUtils(mockClient)
 	scraper := parsers.KompasScraper{Client: mockClient, Utils: util, Cache: &cache}
-	res, err := scraper.Popular(ginContext)
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
 
 	//assertions
 	assert.NoError(t, err)
-	assert.Equal(t, 2, len(res)) //2 is sum of popUrls slice in kompas_parser
+	assert.Equal(t, 5, len(res)) //5 is sum of popUrls slice in kompas_parser
 
 	//make sure data inserted to cache
 	resdata, ok := cache.Items.Data.([]models.Article)
 	assert.True(t, ok)
 	assert.NotNil(t, resdata)
-	assert.Equal(t, 2, len(resdata))
+	assert.Equal(t, 5, len(resdata))
 }
 
-func createTestGinContext() *gin.Context {
-	// Create a ResponseRecorder (a test HTTP response writer)
-	w := httptest.NewRecorder()
-	// Create a new Gin context
-	c, _ := gin.CreateTestContext(w)
+const tribunListHTML = `
+	<html>
+		<ul class="lsi">
+			<li class="ptb15">
+				<div class="fr mt5 pos_rel">
+					<a href="https://www.tribunnews.com/nasional/2024/12/02/kpk-periksa-saksi-kasus-korupsi">
+						<img src="https://asset-2.tstatic.net/tribunnews/foto/bank/thumbnails2/2024/12/02/kpk-periksa-saksi.jpg" alt="KPK Periksa Saksi Kasus Korupsi">
+					</a>
+				</div>
+				<div class="mr140">
+					<h3 class="f16 fbo"><a href="https://www.tribunnews.com/nasional/2024/12/02/kpk-periksa-saksi-kasus-korupsi" class="f20 ln24 fbo txt-oev-2">KPK Periksa Saksi Kasus Korupsi</a></h3>
+					<div class="grey pt5"><time class="foot timeago" title="Senin, 2 Desember 2024 10:15 WIB">1 jam lalu</time></div>
+				</div>
+			</li>
+			<li class="ptb15">
+				<div class="mr140">
+					<h3 class="f16 fbo"><a href="https://www.tribunnews.com/sport/2024/12/02/timnas-indonesia-menang">Timnas Indonesia Menang</a></h3>
+					<div class="grey pt5"><time class="foot timeago">Senin, 2 Desember 2024 09:00 WIB</time></div>
+				</div>
+			</li>
+			<li class="ptb15"><div class="mr140">Sponsored</div></li>
+		</ul>
+	</html>`
 
-	// Simulate an HTTP request for context purposes
-	req := httptest.NewRequest("GET", "/", nil)
-	c.Request = req
+func TestSearchTribun(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Responses: map[string]models.ScraperResponse{
+			"https://www.tribunnews.com/search?q=kpk+korupsi": {
+				Body:   tribunListHTML,
+				Status: 200,
+			},
+		},
+		Response: models.ScraperResponse{Status: 404},
+	}
 
-	return c
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	cache := utils.NewCache()
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: cache}
+	result, err := scraper.Search(context.Background(), "kpk korupsi", 1, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "KPK Periksa Saksi Kasus Korupsi", result[0].Title)
+	assert.Equal(t, "Senin, 2 Desember 2024 10:15 WIB", result[0].Date)
+	assert.Equal(t, "2024-12-02T10:15:00+07:00", result[0].PublishedAt.Format(time.RFC3339))
+	assert.Equal(t, "www.tribunnews.com", result[0].ShortDesc)
+	assert.Equal(t, "https://www.tribunnews.com/nasional/2024/12/02/kpk-periksa-saksi-kasus-korupsi", result[0].SourceUrl)
+	assert.Equal(t, "/article?source=tribun&detailUrl=https%3A%2F%2Fwww.tribunnews.com%2Fnasional%2F2024%2F12%2F02%2Fkpk-periksa-saksi-kasus-korupsi", result[0].URL)
+	assert.Equal(t, "https://asset-2.tstatic.net/tribunnews/foto/bank/images/2024/12/02/kpk-periksa-saksi.jpg", result[0].ImgUrl)
+	assert.Equal(t, "Senin, 2 Desember 2024 09:00 WIB", result[1].Date)
+	assert.Equal(t, "", result[1].ImgUrl)
+}
+
+func TestSearchTribunNon200(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{Status: 503},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	_, err := scraper.Search(context.Background(), "kpk", 1, utils.LinkBuilder{})
+
+	//assertions
+	assert.EqualError(t, err, "error: status code 503")
+}
+
+func TestPopularTribunNoCache(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   tribunListHTML,
+			Status: 200,
+		},
+	}
+	cache := utils.CacheMock{
+		Items: utils.CacheItemsMock{Data: nil, Found: false},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: &cache}
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(res)) //3 popUrls in tribun_parser, 2 articles each
+
+	//make sure data inserted to cache
+	resdata, ok := cache.Items.Data.([]models.Article)
+	assert.True(t, ok)
+	assert.Equal(t, 6, len(resdata))
+}
+
+func TestPopularTribunWithCache(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   tribunListHTML,
+			Status: 200,
+		},
+	}
+	cache := utils.CacheMock{
+		Items: utils.CacheItemsMock{
+			Data:  []models.Article{{URL: "https://www.tribunnews.com/sport/2024/12/02/timnas-indonesia-menang"}},
+			Found: true,
+		},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: &cache}
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+}
+
+func TestDetailTribunStitchesPages(t *testing.T) {
+	//prepare data
+	detailUrl := "https://www.tribunnews.com/nasional/2024/12/02/kpk-periksa-saksi-kasus-korupsi"
+	page1 := `
+	<html>
+		<h1 id="arttitle">KPK Periksa Saksi Kasus Korupsi</h1>
+		<div id="penulis">Penulis: Test Author</div>
+		<time>Senin, 2 Desember 2024 10:15 WIB</time>
+		<div class="imgfull_div"><img src="https://asset-2.tstatic.net/tribunnews/foto/bank/images/kpk.jpg" /></div>
+		<div class="side-article txt-article">
+			<p>Paragraf satu.</p>
+			<p class="baca">Baca juga: <a href="https://www.tribunnews.com/lain">Lain</a></p>
+			<div id="div-gpt-ad-123">ad</div>
+			<div class="paging">
+				<a href="?page=2">2</a>
+				<a href="https://www.tribunnews.com/nasional/2024/12/02/kpk-periksa-saksi-kasus-korupsi?page=3">3</a>
+				<a href="?page=2">Next</a>
+			</div>
+		</div>
+	</html>`
+	page2 := `<html><div class="side-article txt-article"><p>Paragraf dua dengan <a href="https://www.tribunnews.com/nasional/2024/12/01/berita">tautan</a>.</p></div></html>`
+	page3 := `<html><div class="side-article txt-article"><p>Paragraf tiga.</p></div></html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Responses: map[string]models.ScraperResponse{
+			detailUrl:             {Body: page1, Status: 200},
+			detailUrl + "?page=2": {Body: page2, Status: 200},
+			detailUrl + "?page=3": {Body: page3, Status: 200},
+		},
+		Response: models.ScraperResponse{Status: 404},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Detail(context.Background(), detailUrl, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "KPK Periksa Saksi Kasus Korupsi", result.Title)
+	assert.Equal(t, "Penulis: Test Author", result.Author)
+	assert.Equal(t, "Senin, 2 Desember 2024 10:15 WIB", result.Date)
+	assert.Equal(t, "https://asset-2.tstatic.net/tribunnews/foto/bank/images/kpk.jpg", result.ImgUrl)
+	assert.Contains(t, result.Content, "Paragraf satu.")
+	assert.Contains(t, result.Content, "Paragraf dua")
+	assert.Contains(t, result.Content, "Paragraf tiga.")
+	assert.Less(t, strings.Index(result.Content, "Paragraf dua"), strings.Index(result.Content, "Paragraf tiga."))
+	assert.Contains(t, result.Content, "/detail?source=tribun&amp;detailUrl=")
+	assert.NotContains(t, result.Content, "Baca juga")
+	assert.NotContains(t, result.Content, "div-gpt-ad")
+	assert.NotContains(t, result.Content, "paging")
+}
+
+func TestDetailTribunSinglePage(t *testing.T) {
+	//prepare data
+	mockHTML := `
+	<html>
+		<h1 id="arttitle">Test Title</h1>
+		<div class="side-article txt-article">Test Content</div>
+	</html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   mockHTML,
+			Status: 200,
+		},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.TribunScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Detail(context.Background(), "https://www.tribunnews.com/x", utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Title", result.Title)
+	assert.Equal(t, "Test Content", result.Content)
+}
+
+const cnnIndonesiaListHTML = `
+	<html>
+		<div class="flex flex-col gap-5">
+			<article class="flex-grow">
+				<a href="https://www.cnnindonesia.com/nasional/20241202101530-12-1172345/prabowo-lantik-pejabat-baru" class="flex group items-center gap-4">
+					<span class="flex-none"><img src="https://akcdn.detik.net.id/visual/2024/12/02/prabowo_169.jpeg?w=360&q=90" alt="Prabowo"></span>
+					<span class="flex flex-col">
+						<h2 class="text-base text-cnn_black_light">Prabowo Lantik Pejabat Baru</h2>
+						<span class="text-xs text-cnn_black_light3">Nasional</span>
+					</span>
+				</a>
+			</article>
+			<article class="flex-grow">
+				<a href="https://www.cnnindonesia.com/olahraga/tanpa-tanggal" class="flex group items-center gap-4">
+					<span class="flex flex-col"><h2>Tanpa Tanggal</h2></span>
+				</a>
+			</article>
+			<article class="flex-grow"><div class="adv">iklan</div></article>
+		</div>
+	</html>`
+
+func TestSearchCNNIndonesia(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Responses: map[string]models.ScraperResponse{
+			"https://www.cnnindonesia.com/search?query=prabowo+lantik": {
+				Body:   cnnIndonesiaListHTML,
+				Status: 200,
+			},
+		},
+		Response: models.ScraperResponse{Status: 404},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.CNNIndonesiaScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Search(context.Background(), "prabowo lantik", 1, utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "Prabowo Lantik Pejabat Baru", result[0].Title)
+	assert.Equal(t, "02/12/2024, 10:15 WIB", result[0].Date)
+	assert.Equal(t, "2024-12-02T10:15:30+07:00", result[0].PublishedAt.Format(time.RFC3339))
+	assert.Equal(t, "www.cnnindonesia.com", result[0].ShortDesc)
+	assert.Equal(t, "https://akcdn.detik.net.id/visual/2024/12/02/prabowo_169.jpeg?w=800&q=90", result[0].ImgUrl)
+	assert.Equal(t, "/article?source=ccnid&detailUrl=https%3A%2F%2Fwww.cnnindonesia.com%2Fnasional%2F20241202101530-12-1172345%2Fprabowo-lantik-pejabat-baru", result[0].URL)
+	assert.Equal(t, "", result[1].Date)
+	assert.Nil(t, result[1].PublishedAt)
+}
+
+func TestPopularCNNIndonesiaNoResult(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   `<html></html>`,
+			Status: 200,
+		},
+	}
+	cache := utils.CacheMock{
+		Items: utils.CacheItemsMock{Data: nil, Found: false},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.CNNIndonesiaScraper{Client: mockClient, Utils: util, Cache: &cache}
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(res))
+
+	//make sure no data inserted to cache if result length is 0
+	_, ok := cache.Items.Data.([]models.Article)
+	assert.False(t, ok)
+}
+
+func TestPopularCNNIndonesiaNoCache(t *testing.T) {
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   cnnIndonesiaListHTML,
+			Status: 200,
+		},
+	}
+	cache := utils.CacheMock{
+		Items: utils.CacheItemsMock{Data: nil, Found: false},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.CNNIndonesiaScraper{Client: mockClient, Utils: util, Cache: &cache}
+	res, err := scraper.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 14, len(res)) //7 popUrls in cnnindonesia_parser, 2 articles each
+
+	//make sure data inserted to cache
+	resdata, ok := cache.Items.Data.([]models.Article)
+	assert.True(t, ok)
+	assert.Equal(t, 14, len(resdata))
+}
+
+func TestDetailCNNIndonesia(t *testing.T) {
+	//prepare data
+	mockHTML := `
+	<html>
+		<head>
+			<meta name="author" content="Test Author">
+			<meta name="publishdate" content="2024/12/02 10:15:30">
+			<meta property="og:image" content="https://akcdn.detik.net.id/visual/2024/12/02/prabowo_169.jpeg">
+		</head>
+		<body>
+			<h1 class="mb-2 text-[28px]">Test Title</h1>
+			<div class="detail-text">
+				<p>Test Content</p>
+				<div class="adv-detail">Iklan</div>
+				<div id="div-gpt-ad-1">ad</div>
+				<p><a href="https://www.cnnindonesia.com/nasional/20241201-12-1/lain">Lain</a></p>
+			</div>
+		</body>
+	</html>`
+
+	//mock
+	mockClient := utils.HttpClientMock{
+		Response: models.ScraperResponse{
+			Body:   mockHTML,
+			Status: 200,
+		},
+	}
+
+	//do test
+	util := utils.NewScrapeUtils(mockClient)
+	scraper := parsers.CNNIndonesiaScraper{Client: mockClient, Utils: util, Cache: utils.NewCache()}
+	result, err := scraper.Detail(context.Background(), "https://www.cnnindonesia.com/nasional/x", utils.LinkBuilder{})
+
+	//assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Title", result.Title)
+	assert.Equal(t, "Test Author", result.Author)
+	assert.Equal(t, "02/12/2024, 10:15 WIB", result.Date)
+	assert.Equal(t, "https://akcdn.detik.net.id/visual/2024/12/02/prabowo_169.jpeg", result.ImgUrl)
+	assert.Contains(t, result.Content, "<p>Test Content</p>")
+	assert.Contains(t, result.Content, "/detail?source=ccnid&amp;detailUrl=")
+	assert.NotContains(t, result.Content, "Iklan")
+	assert.NotContains(t, result.Content, "div-gpt-ad")
 }
@@ -0,0 +1,53 @@
+package parsers
+
+import (
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// NewRegistry builds a scraper.Registry containing every site Gober supports.
+// Adding a site means adding its parser and one entry here.
+func NewRegistry(client utils.HTTPClient, scrapeUtils utils.ScrapeUtils, cache utils.CacheOps) (*scraper.Registry, error) {
+	sources := []scraper.Source{
+		{
+			Name:         "detik",
+			DisplayName:  "detik.com",
+			Homepage:     "https://www.detik.com",
+			Hosts:        []string{"detik.com"},
+			Capabilities: scraper.Capabilities{Search: true, Popular: true, Detail: true, Index: true},
+			Scraper:      DetikScraper{Client: client, Utils: scrapeUtils, Cache: cache},
+		},
+		{
+			Name:         "kompas",
+			DisplayName:  "kompas.com",
+			Homepage:     "https://www.kompas.com",
+			Hosts:        []string{"kompas.com"},
+			Capabilities: scraper.Capabilities{Search: true, Popular: true, Detail: true, Index: true},
+			Scraper:      KompasScraper{Client: client, Utils: scrapeUtils, Cache: cache},
+		},
+		{
+			Name:         "tribun",
+			DisplayName:  "tribunnews.com",
+			Homepage:     "https://www.tribunnews.com",
+			Hosts:        []string{"tribunnews.com"},
+			Capabilities: scraper.Capabilities{Search: true, Popular: true, Detail: true, Index: true},
+			Scraper:      TribunScraper{Client: client, Utils: scrapeUtils, Cache: cache},
+		},
+		{
+			Name:         "ccnid",
+			DisplayName:  "cnnindonesia.com",
+			Homepage:     "https://www.cnnindonesia.com",
+			Hosts:        []string{"cnnindonesia.com"},
+			Capabilities: scraper.Capabilities{Search: true, Popular: true, Detail: true, Index: true},
+			Scraper:      CNNIndonesiaScraper{Client: client, Utils: scrapeUtils, Cache: cache},
+		},
+	}
+
+	registry := scraper.NewRegistry()
+	for _, src := range sources {
+		if err := registry.Register(src); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,198 @@
+package parsers
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+type TribunScraper struct {
+	Client utils.HTTPClient
+	Utils  utils.ScrapeUtils
+	Cache  utils.CacheOps
+}
+
+func (t TribunScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	searchUrl := "https://www.tribunnews.com/search?q=" + url.QueryEscape(keyword)
+	if page > 1 {
+		searchUrl += "&page=" + strconv.Itoa(page)
+	}
+	doc, err := t.fetchDocument(ctx, searchUrl)
+	if err != nil {
+		return []models.Article{}, err
+	}
+
+	return fetchArticlesTribun(doc, links), nil
+}
+
+func (t TribunScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	result, err := utils.FetchAs(ctx, t.Cache, "tribun:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		popUrls := []string{
+			"https://www.tribunnews.com/populer",
+			"https://www.tribunnews.com/populer?page=2",
+			"https://www.tribunnews.com/populer?page=3",
+		}
+
+		result := t.Utils.FetchListArticles(ctx, fetchArticlesTribun, popUrls, utils.LinkBuilder{})
+
+		log.Printf("Tribun articles: %v", len(result))
+		if len(result) == 0 {
+			return result, 0, nil
+		}
+		return result, 5 * time.Minute, nil
+	})
+	return links.Resolve(result), err
+}
+
+// Latest lists the newest articles from tribunnews.com's index page. Unlike
+// Popular it isn't cached: it exists for the crawler to find new articles.
+func (t TribunScraper) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexUrls := []string{
+		"https://www.tribunnews.com/index-news",
+	}
+
+	result := t.Utils.FetchListArticles(ctx, fetchArticlesTribun, indexUrls, links)
+	log.Printf("Tribun latest articles: %v", len(result))
+	return result, nil
+}
+
+func (t TribunScraper) Detail(ctx context.Context, detailUrl string, links utils.LinkBuilder) (models.Article, error) {
+	return utils.FetchAs(ctx, t.Cache, "tribun:"+canonical.ID(detailUrl), func(ctx context.Context) (models.Article, time.Duration, error) {
+		article, err := t.fetchDetail(ctx, detailUrl)
+		return article, 5 * time.Minute, err
+	})
+}
+
+func (t TribunScraper) fetchDetail(ctx context.Context, detailUrl string) (models.Article, error) {
+	doc, err := t.fetchDocument(ctx, detailUrl)
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	article := models.Article{}
+	article.URL = detailUrl
+	article.ID = canonical.ID(detailUrl)
+	article.Title = strings.TrimSpace(doc.Find("h1#arttitle").Text())
+	article.Author = strings.TrimSpace(doc.Find("div#penulis").Text())
+	article.Date = strings.TrimSpace(doc.Find("time").First().Text())
+	article.PublishedAt = utils.PublishedAt(article.Date)
+	article.ImgUrl, _ = doc.Find("div.imgfull_div").Find("img").Attr("src")
+
+	// Tribun splits long stories across ?page=N; stitch every page's body together.
+	// Pagination links are read before cleaning, which strips the paging block.
+	pageUrls := tribunPageUrls(doc, detailUrl)
+	contents := []string{tribunContent(doc, detailUrl)}
+	for _, pageUrl := range pageUrls {
+		pageDoc, err := t.fetchDocument(ctx, pageUrl)
+		if err != nil {
+			log.Printf("failed to fetch tribun page %s: %v", pageUrl, err)
+			break
+		}
+		contents = append(contents, tribunContent(pageDoc, pageUrl))
+	}
+	article.Content = strings.Join(contents, "\n")
+
+	return article, nil
+}
+
+func (t TribunScraper) fetchDocument(ctx context.Context, pageUrl string) (*goquery.Document, error) {
+	resp, err := t.Client.Get(ctx, pageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != 200 {
+		return nil, utils.StatusError{Code: resp.Status}
+	}
+
+	return goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
+}
+
+func tribunContent(doc *goquery.Document, pageUrl string) string {
+	content := utils.MainContent(doc, doc.Find("div.side-article.txt-article"), pageUrl)
+	utils.RewriteContentLinks(content)
+	return utils.CleanContent(content,
+		".paging",
+		".baca",
+		`[id^="div-gpt-ad"]`,
+		".ads-placeholder",
+	)
+}
+
+// tribunPageUrls returns the absolute URLs of page 2 onwards, in page order,
+// as linked from the article's pagination block.
+func tribunPageUrls(doc *goquery.Document, detailUrl string) []string {
+	base, err := url.Parse(detailUrl)
+	if err != nil {
+		return nil
+	}
+
+	pages := map[int]string{}
+	lastPage := 1
+	doc.Find("div.paging a[href]").Each(func(i int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		ref, err := base.Parse(href)
+		if err != nil {
+			return
+		}
+		page, err := strconv.Atoi(ref.Query().Get("page"))
+		if err != nil || page <= 1 {
+			return
+		}
+		if _, seen := pages[page]; !seen {
+			pages[page] = ref.String()
+		}
+		if page > lastPage {
+			lastPage = page
+		}
+	})
+
+	var pageUrls []string
+	for page := 2; page <= lastPage; page++ {
+		if pageUrl, ok := pages[page]; ok {
+			pageUrls = append(pageUrls, pageUrl)
+		}
+	}
+	return pageUrls
+}
+
+func fetchArticlesTribun(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
+	var listArticles []models.Article
+	doc.Find("li.ptb15").Each(func(i int, s *goquery.Selection) {
+		anchor := s.Find("h3 a")
+		resultUrl, attrExists := anchor.Attr("href")
+		if !attrExists {
+			return
+		}
+
+		article := models.Article{}
+		article.URL = links.Article("tribun", resultUrl)
+		article.SourceUrl = resultUrl
+		article.ID = canonical.ID(article.SourceUrl)
+		article.Title = strings.TrimSpace(anchor.Text())
+
+		parsedUrl, err := url.Parse(resultUrl)
+		if err == nil {
+			article.ShortDesc = parsedUrl.Host
+		}
+
+		img, imgExists := s.Find("img").Attr("src")
+		if imgExists {
+			article.ImgUrl = utils.EnhanceImageURL(img)
+		}
+
+		article.Date = strings.TrimSpace(s.Find("time").AttrOr("title", s.Find("time").Text()))
+		article.PublishedAt = utils.PublishedAt(article.Date)
+
+		listArticles = append(listArticles, article)
+	})
+	return listArticles
+}
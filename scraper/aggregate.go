@@ -0,0 +1,237 @@
+package scraper
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akhmadreiza/gober/canonical"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// AllSources is the source query value that selects every registered source.
+const AllSources = "all"
+
+// SourceResult reports how a single source fared in a multi-source request.
+type SourceResult struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+	Desc   string `json:"desc,omitempty"`
+}
+
+// Aggregated is the merged outcome of fanning a request out to several sources.
+type Aggregated struct {
+	Articles []models.Article
+	Sources  []SourceResult
+}
+
+func init() {
+	utils.RegisterCacheType[Aggregated]("aggregated")
+}
+
+// Succeeded reports whether at least one source answered successfully.
+func (a Aggregated) Succeeded() bool {
+	for _, src := range a.Sources {
+		if src.Status == "Success" {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete reports whether every source answered successfully.
+func (a Aggregated) Complete() bool {
+	for _, src := range a.Sources {
+		if src.Status != "Success" {
+			return false
+		}
+	}
+	return true
+}
+
+// IsMultiSource reports whether a source query value names more than one source.
+func IsMultiSource(param string) bool {
+	return param == AllSources || strings.Contains(param, ",")
+}
+
+// Names expands a source query value ("all" or "detik,kompas") into source
+// names. "all" only yields sources supporting op; explicitly listed names are
+// returned as given so unsupported ones can be reported per source.
+func (r *Registry) Names(param string, op Operation) []string {
+	var names []string
+	if param == AllSources {
+		for _, src := range r.Sources() {
+			if src.Capabilities.Supports(op) {
+				names = append(names, src.Name)
+			}
+		}
+		return names
+	}
+
+	seen := map[string]bool{}
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// SearchAll runs keyword against the given upstream result page of every named
+// source concurrently. Results are
+// de-duplicated by canonical URL and sorted newest first; a failing source is
+// reported in Sources instead of failing the whole search.
+func (r *Registry) SearchAll(ctx context.Context, names []string, keyword string, page int, links utils.LinkBuilder) Aggregated {
+	lists, results := r.fanOut(names, OpSearch, func(s NewsScraper) ([]models.Article, error) {
+		return s.Search(ctx, keyword, page, links)
+	})
+
+	var merged []models.Article
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	merged = dedupe(merged)
+	SortByPublishTime(merged)
+
+	return Aggregated{Articles: merged, Sources: results}
+}
+
+// PopularAll merges the popular lists of every named source into a single
+// feed ordered by RankPopular.
+func (r *Registry) PopularAll(ctx context.Context, names []string, links utils.LinkBuilder, weights RankWeights) Aggregated {
+	lists, results := r.fanOut(names, OpPopular, func(s NewsScraper) ([]models.Article, error) {
+		return s.Popular(ctx, links)
+	})
+
+	for i := range lists {
+		lists[i] = dedupe(lists[i])
+	}
+	merged := dedupe(RankPopular(lists, weights, time.Now()))
+
+	return Aggregated{Articles: merged, Sources: results}
+}
+
+// fanOut calls every named source concurrently. lists and results are
+// indexed like names; each article is tagged with the source it came from.
+func (r *Registry) fanOut(names []string, op Operation, call func(NewsScraper) ([]models.Article, error)) (lists [][]models.Article, results []SourceResult) {
+	results = make([]SourceResult, len(names))
+	lists = make([][]models.Article, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		src, ok := r.Source(name)
+		if !ok {
+			results[i] = SourceResult{Source: name, Status: "Failed", Desc: "scrape " + name + " not supported"}
+			continue
+		}
+		if !src.Capabilities.Supports(op) {
+			results[i] = SourceResult{Source: name, Status: "Failed", Desc: string(op) + " is not supported for source " + name}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+			articles, err := call(src.Scraper)
+			if err != nil {
+				results[i] = SourceResult{Source: src.Name, Status: "Failed", Desc: err.Error()}
+				return
+			}
+			tagged := make([]models.Article, len(articles))
+			for j, article := range articles {
+				if article.Source == "" {
+					article.Source = src.Name
+				}
+				article.ID = canonical.Of(article)
+				tagged[j] = article
+			}
+			lists[i] = tagged
+			results[i] = SourceResult{Source: src.Name, Status: "Success", Count: len(articles)}
+		}(i, src)
+	}
+	wg.Wait()
+
+	return lists, results
+}
+
+// dedupe drops every article whose ID was already seen, keeping the first
+// occurrence.
+func dedupe(articles []models.Article) []models.Article {
+	var unique []models.Article
+	seen := map[string]bool{}
+	for _, article := range articles {
+		key := article.Source + ":" + canonical.Of(article)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, article)
+	}
+	return unique
+}
+
+// SortByPublishTime orders articles newest first. Articles whose date can't be
+// parsed keep their relative order and go last.
+func SortByPublishTime(articles []models.Article) {
+	type keyed struct {
+		article   models.Article
+		published time.Time
+		ok        bool
+	}
+	list := make([]keyed, len(articles))
+	for i, article := range articles {
+		published, ok := PublishTime(article)
+		list[i] = keyed{article, published, ok}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].ok != list[j].ok {
+			return list[i].ok
+		}
+		return list[i].published.After(list[j].published)
+	})
+
+	for i := range list {
+		articles[i] = list[i].article
+	}
+}
+
+// PublishTime returns the article's publish time, falling back to parsing the
+// raw Date for articles that predate PublishedAt (e.g. stale cache entries).
+func PublishTime(article models.Article) (time.Time, bool) {
+	if article.PublishedAt != nil {
+		return *article.PublishedAt, true
+	}
+	return utils.ParseIndonesianTime(article.Date)
+}
+
+// FilterByPublishTime keeps articles published within [since, until]. A nil
+// bound is open; articles without a known publish time are dropped whenever
+// a bound is set.
+func FilterByPublishTime(articles []models.Article, since, until *time.Time) []models.Article {
+	if since == nil && until == nil {
+		return articles
+	}
+	filtered := []models.Article{}
+	for _, article := range articles {
+		published, ok := PublishTime(article)
+		if !ok {
+			continue
+		}
+		if since != nil && published.Before(*since) {
+			continue
+		}
+		if until != nil && published.After(*until) {
+			continue
+		}
+		filtered = append(filtered, article)
+	}
+	return filtered
+}
@@ -0,0 +1,125 @@
+package scraper_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedScraper struct {
+	articles []models.Article
+	err      error
+}
+
+func (f fixedScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	return f.articles, f.err
+}
+
+func (f fixedScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return f.articles, f.err
+}
+
+func (f fixedScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	return models.Article{}, f.err
+}
+
+func newAggregateRegistry(t *testing.T) *scraper.Registry {
+	registry := scraper.NewRegistry()
+	all := scraper.Capabilities{Search: true, Popular: true, Detail: true}
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "detik",
+		Capabilities: all,
+		Scraper: fixedScraper{articles: []models.Article{
+			{Title: "detik lama", SourceUrl: "https://news.detik.com/berita/d-1/lama?single=1", Date: "Minggu, 01 Des 2024 08:00 WIB"},
+			{Title: "detik baru", SourceUrl: "https://news.detik.com/berita/d-2/baru?single=1", Date: "Senin, 02 Des 2024 09:00 WIB"},
+			{Title: "detik tanpa tanggal", SourceUrl: "https://news.detik.com/berita/d-3/x?single=1", Date: "-"},
+			{Title: "detik baru (mobile)", SourceUrl: "https://m.detik.com/news/berita/d-2/baru", Date: "Senin, 02 Des 2024 09:00 WIB"},
+		}},
+	}))
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "kompas",
+		Capabilities: all,
+		Scraper: fixedScraper{articles: []models.Article{
+			{Title: "kompas tengah", SourceUrl: "https://nasional.kompas.com/read/2024/12/01/12000001/tengah?page=all", Date: "01/12/2024, 12:00 WIB"},
+			{Title: "kompas duplikat", SourceUrl: "https://nasional.kompas.com/read/2024/12/01/12000001/tengah", Date: "01/12/2024, 12:00 WIB"},
+		}},
+	}))
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "tribun",
+		Capabilities: all,
+		Scraper:      fixedScraper{err: errors.New("error: status code 503")},
+	}))
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "ccnid",
+		Capabilities: scraper.Capabilities{Popular: true, Detail: true},
+		Scraper:      fixedScraper{},
+	}))
+	return registry
+}
+
+func TestRegistryNames(t *testing.T) {
+	registry := newAggregateRegistry(t)
+
+	assert.Equal(t, []string{"detik", "kompas", "tribun"}, registry.Names("all", scraper.OpSearch))
+	assert.Equal(t, []string{"detik", "kompas", "tribun", "ccnid"}, registry.Names("all", scraper.OpPopular))
+	assert.Equal(t, []string{"kompas", "detik"}, registry.Names(" kompas, detik,,kompas", scraper.OpSearch))
+	assert.True(t, scraper.IsMultiSource("all"))
+	assert.True(t, scraper.IsMultiSource("detik,kompas"))
+	assert.False(t, scraper.IsMultiSource("detik"))
+}
+
+func TestSearchAllMergesSortsAndReportsFailures(t *testing.T) {
+	registry := newAggregateRegistry(t)
+
+	result := registry.SearchAll(context.Background(), []string{"detik", "kompas", "tribun", "ccnid", "antara"}, "x", 1, utils.LinkBuilder{})
+
+	assert.True(t, result.Succeeded())
+	titles := []string{}
+	for _, a := range result.Articles {
+		titles = append(titles, a.Title)
+	}
+	assert.Equal(t, []string{"detik baru", "kompas tengah", "detik lama", "detik tanpa tanggal"}, titles)
+	assert.Equal(t, "d-2", result.Articles[0].ID)
+	assert.Equal(t, "read/2024/12/01/12000001", result.Articles[1].ID)
+
+	assert.Equal(t, []scraper.SourceResult{
+		{Source: "detik", Status: "Success", Count: 4},
+		{Source: "kompas", Status: "Success", Count: 2},
+		{Source: "tribun", Status: "Failed", Desc: "error: status code 503"},
+		{Source: "ccnid", Status: "Failed", Desc: "search is not supported for source ccnid"},
+		{Source: "antara", Status: "Failed", Desc: "scrape antara not supported"},
+	}, result.Sources)
+}
+
+func TestSearchAllEverySourceFailed(t *testing.T) {
+	registry := newAggregateRegistry(t)
+
+	result := registry.SearchAll(context.Background(), []string{"tribun"}, "x", 1, utils.LinkBuilder{})
+
+	assert.False(t, result.Succeeded())
+	assert.Equal(t, 0, len(result.Articles))
+}
+
+func TestFilterByPublishTime(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	published := time.Date(2024, 12, 2, 9, 0, 0, 0, wib)
+	articles := []models.Article{
+		{Title: "structured", PublishedAt: &published},
+		{Title: "raw only", Date: "Minggu, 01 Des 2024 08:00 WIB"},
+		{Title: "unknown", Date: "-"},
+	}
+
+	assert.Equal(t, articles, scraper.FilterByPublishTime(articles, nil, nil))
+
+	since := time.Date(2024, 12, 2, 0, 0, 0, 0, wib)
+	assert.Equal(t, []string{"structured"}, titlesOf(scraper.FilterByPublishTime(articles, &since, nil)))
+
+	until := time.Date(2024, 12, 1, 23, 59, 0, 0, wib)
+	assert.Equal(t, []string{"raw only"}, titlesOf(scraper.FilterByPublishTime(articles, nil, &until)))
+}
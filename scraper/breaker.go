@@ -0,0 +1,263 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+)
+
+// ErrCircuitOpen is returned without calling a source whose breaker is open.
+var ErrCircuitOpen = errors.New("source temporarily unavailable")
+
+// Default breaker tuning: five failures in a row open the circuit, and a
+// probe is let through every 30 seconds until one succeeds.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerState is where a Breaker is in its closed, open, half-open cycle.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerStatus is a snapshot of one breaker, as reported on /health.
+type BreakerStatus struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	// RetryIn is the time left before the next probe while open.
+	RetryIn string `json:"retry_in,omitempty"`
+}
+
+// Breaker is a circuit breaker for one source. After Threshold consecutive
+// failures it opens and rejects calls for Cooldown, then lets one probe
+// through (half-open): success closes it again, failure reopens it.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, state: BreakerClosed}
+}
+
+// allow reports whether a call may go ahead, and if not, how long until the
+// next probe.
+func (b *Breaker) allow(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.openedAt.Add(b.Cooldown).Sub(now); wait > 0 {
+			return false, wait
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true, 0
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, b.Cooldown
+		}
+		b.probing = true
+	}
+	return true, 0
+}
+
+func (b *Breaker) record(ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+}
+
+// cancelled releases a half-open probe that ended without a verdict, e.g.
+// because its caller went away.
+func (b *Breaker) cancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Status returns the breaker's current state.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state == BreakerOpen {
+		if wait := time.Until(b.openedAt.Add(b.Cooldown)); wait > 0 {
+			status.RetryIn = wait.Round(time.Second).String()
+		}
+	}
+	return status
+}
+
+// Breakers holds one Breaker per source name.
+type Breakers struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{Threshold: threshold, Cooldown: cooldown, breakers: map[string]*Breaker{}}
+}
+
+// For returns the breaker for source, creating it on first use.
+func (bs *Breakers) For(source string) *Breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.breakers[source]
+	if !ok {
+		b = NewBreaker(bs.Threshold, bs.Cooldown)
+		bs.breakers[source] = b
+	}
+	return b
+}
+
+// Statuses returns every breaker's state keyed by source name.
+func (bs *Breakers) Statuses() map[string]BreakerStatus {
+	bs.mu.Lock()
+	names := make([]string, 0, len(bs.breakers))
+	for name := range bs.breakers {
+		names = append(names, name)
+	}
+	bs.mu.Unlock()
+	sort.Strings(names)
+
+	statuses := make(map[string]BreakerStatus, len(names))
+	for _, name := range names {
+		statuses[name] = bs.For(name).Status()
+	}
+	return statuses
+}
+
+// Guarded puts Breaker between Next and its site. Errors from an unhealthy
+// site count as failures, and so do empty popular and index lists, since list
+// scrapers report a site that is down as an empty result. An empty search is
+// a valid answer.
+//
+// Popular and Detail are guarded inside Next's cache loads, so cached and
+// stale entries are still served while the circuit is open. Search and
+// Latest aren't cached and are guarded around the whole call.
+type Guarded struct {
+	Source  string
+	Next    NewsScraper
+	Breaker *Breaker
+}
+
+func (g Guarded) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	var articles []models.Article
+	err := g.call(ctx, func() (bool, error) {
+		var err error
+		articles, err = g.Next.Search(ctx, keyword, page, links)
+		return err == nil, err
+	})
+	return articles, err
+}
+
+func (g Guarded) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return g.Next.Popular(utils.WithLoadWrapper(ctx, g.guardLoad(func(value interface{}) bool {
+		articles, _ := value.([]models.Article)
+		return len(articles) > 0
+	})), links)
+}
+
+// Latest forwards to Next when it is an Indexer.
+func (g Guarded) Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	indexer, ok := g.Next.(Indexer)
+	if !ok {
+		return nil, fmt.Errorf("%v has no index", g.Source)
+	}
+	var articles []models.Article
+	err := g.call(ctx, func() (bool, error) {
+		var err error
+		articles, err = indexer.Latest(ctx, links)
+		return err == nil && len(articles) > 0, err
+	})
+	return articles, err
+}
+
+func (g Guarded) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	return g.Next.Detail(utils.WithLoadWrapper(ctx, g.guardLoad(func(interface{}) bool {
+		return true
+	})), url, links)
+}
+
+// guardLoad runs a cache load through call. healthy tells whether a value
+// loaded without error shows the site working.
+func (g Guarded) guardLoad(healthy func(value interface{}) bool) func(utils.Loader) utils.Loader {
+	return func(load utils.Loader) utils.Loader {
+		return func(ctx context.Context) (interface{}, time.Duration, error) {
+			var value interface{}
+			var ttl time.Duration
+			err := g.call(ctx, func() (bool, error) {
+				var err error
+				value, ttl, err = load(ctx)
+				return err == nil && healthy(value), err
+			})
+			return value, ttl, err
+		}
+	}
+}
+
+// call runs fn if the breaker allows it and records the outcome. Failures
+// caused by the caller (a cancelled request or a bad URL) or by Gober's own
+// policies say nothing about the site's health and are not counted.
+func (g Guarded) call(ctx context.Context, fn func() (ok bool, err error)) error {
+	allowed, retryIn := g.Breaker.allow(time.Now())
+	if !allowed {
+		return fmt.Errorf("%w: %s failed repeatedly, retrying in %s", ErrCircuitOpen, g.Source, retryIn.Round(time.Second))
+	}
+
+	ok, err := fn()
+	if !ok && (ctx.Err() != nil || (err != nil && !siteFailure(err))) {
+		g.Breaker.cancelled()
+		return err
+	}
+	g.Breaker.record(ok, time.Now())
+	return err
+}
+
+// siteFailure reports whether err means the site is unhealthy: it couldn't
+// be reached, or answered with a 5xx or 429. Other 4xx responses are about
+// the page asked for, and the rest are refusals on Gober's side.
+func siteFailure(err error) bool {
+	var status utils.StatusError
+	if errors.As(err, &status) {
+		return status.Code >= http.StatusInternalServerError || status.Code == http.StatusTooManyRequests
+	}
+	for _, refused := range []error{utils.ErrDisallowed, utils.ErrForbiddenDestination, utils.ErrNotHTML, utils.ErrTooLarge} {
+		if errors.Is(err, refused) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package scraper_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyScraper caches Popular and Detail like the parsers do. Popular fails
+// while down is set, counting every load, and Detail fails with detailErr.
+type flakyScraper struct {
+	stubScraper
+	down      *bool
+	calls     *int
+	detailErr error
+	cache     utils.CacheOps
+}
+
+func newFlakyScraper(down *bool, calls *int) flakyScraper {
+	return flakyScraper{down: down, calls: calls, cache: utils.NewCache()}
+}
+
+func (f flakyScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return utils.FetchAs(ctx, f.cache, "kompas:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		*f.calls++
+		if *f.down {
+			return nil, 0, utils.StatusError{Code: 503}
+		}
+		return []models.Article{{Title: "Satu"}}, time.Minute, nil
+	})
+}
+
+func (f flakyScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	return utils.FetchAs(ctx, f.cache, url, func(ctx context.Context) (models.Article, time.Duration, error) {
+		return models.Article{}, 0, f.detailErr
+	})
+}
+
+func TestGuardedOpensAfterRepeatedFailures(t *testing.T) {
+	//prepare data
+	down, calls := true, 0
+	breaker := scraper.NewBreaker(3, time.Hour)
+	guarded := scraper.Guarded{Source: "kompas", Next: newFlakyScraper(&down, &calls), Breaker: breaker}
+
+	//do test
+	for i := 0; i < 3; i++ {
+		guarded.Popular(context.Background(), utils.LinkBuilder{})
+	}
+	_, err := guarded.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.ErrorIs(t, err, scraper.ErrCircuitOpen)
+	assert.Contains(t, err.Error(), "kompas failed repeatedly")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, scraper.BreakerOpen, breaker.Status().State)
+}
+
+func TestGuardedHalfOpenProbe(t *testing.T) {
+	//prepare data
+	down, calls := true, 0
+	breaker := scraper.NewBreaker(1, 10*time.Millisecond)
+	guarded := scraper.Guarded{Source: "kompas", Next: newFlakyScraper(&down, &calls), Breaker: breaker}
+	guarded.Popular(context.Background(), utils.LinkBuilder{})
+	assert.Equal(t, scraper.BreakerOpen, breaker.Status().State)
+
+	//do test
+	time.Sleep(15 * time.Millisecond)
+	_, probeErr := guarded.Popular(context.Background(), utils.LinkBuilder{})
+	_, rejectedErr := guarded.Popular(context.Background(), utils.LinkBuilder{})
+	time.Sleep(15 * time.Millisecond)
+	down = false
+	articles, err := guarded.Popular(context.Background(), utils.LinkBuilder{})
+
+	//assertions
+	assert.EqualError(t, probeErr, "error: status code 503")
+	assert.ErrorIs(t, rejectedErr, scraper.ErrCircuitOpen)
+	assert.Nil(t, err)
+	assert.Len(t, articles, 1)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, scraper.BreakerStatus{State: scraper.BreakerClosed}, breaker.Status())
+}
+
+func TestGuardedIgnoresCancelledCalls(t *testing.T) {
+	//prepare data
+	down, calls := true, 0
+	breaker := scraper.NewBreaker(1, time.Hour)
+	guarded := scraper.Guarded{Source: "kompas", Next: newFlakyScraper(&down, &calls), Breaker: breaker}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//do test
+	guarded.Popular(ctx, utils.LinkBuilder{})
+
+	//assertions
+	assert.Equal(t, scraper.BreakerClosed, breaker.Status().State)
+}
+
+func TestGuardedServesCacheWhileOpen(t *testing.T) {
+	//prepare data
+	down, calls := false, 0
+	breaker := scraper.NewBreaker(1, time.Hour)
+	flaky := newFlakyScraper(&down, &calls)
+	guarded := scraper.Guarded{Source: "kompas", Next: flaky, Breaker: breaker}
+	_, err := guarded.Popular(context.Background(), utils.LinkBuilder{})
+	assert.Nil(t, err)
+	flaky.detailErr = utils.StatusError{Code: 503}
+	scraper.Guarded{Source: "kompas", Next: flaky, Breaker: breaker}.Detail(context.Background(), "https://www.kompas.com/read/1", utils.LinkBuilder{})
+
+	//do test
+	articles, err := guarded.Popular(context.Background(), utils.LinkBuilder{})
+	_, detailErr := guarded.Detail(context.Background(), "https://www.kompas.com/read/2", utils.LinkBuilder{})
+
+	//assertions
+	assert.Equal(t, scraper.BreakerOpen, breaker.Status().State)
+	assert.Nil(t, err)
+	assert.Len(t, articles, 1)
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, detailErr, scraper.ErrCircuitOpen)
+}
+
+func TestGuardedIgnoresRequestErrors(t *testing.T) {
+	//prepare data
+	breaker := scraper.NewBreaker(1, time.Hour)
+	guard := func(err error) scraper.Guarded {
+		return scraper.Guarded{Source: "kompas", Next: flakyScraper{detailErr: err, cache: utils.NewCache()}, Breaker: breaker}
+	}
+
+	//do test
+	for _, err := range []error{
+		utils.StatusError{Code: 404},
+		fmt.Errorf("failed to fetch URL: %w", utils.ErrNotHTML),
+		fmt.Errorf("failed to fetch URL: %w", utils.ErrForbiddenDestination),
+		utils.ErrDisallowed,
+	} {
+		guard(err).Detail(context.Background(), "https://www.kompas.com/read/1", utils.LinkBuilder{})
+	}
+	closed := breaker.Status()
+	guard(utils.StatusError{Code: 429}).Detail(context.Background(), "https://www.kompas.com/read/1", utils.LinkBuilder{})
+
+	//assertions
+	assert.Equal(t, scraper.BreakerStatus{State: scraper.BreakerClosed}, closed)
+	assert.Equal(t, scraper.BreakerOpen, breaker.Status().State)
+}
+
+func TestBreakersStatuses(t *testing.T) {
+	//prepare data
+	breakers := scraper.NewBreakers(1, time.Minute)
+	down, calls := true, 0
+	guarded := scraper.Guarded{Source: "kompas", Next: newFlakyScraper(&down, &calls), Breaker: breakers.For("kompas")}
+	breakers.For("detik")
+
+	//do test
+	guarded.Popular(context.Background(), utils.LinkBuilder{})
+	statuses := breakers.Statuses()
+
+	//assertions
+	assert.Equal(t, scraper.BreakerClosed, statuses["detik"].State)
+	assert.Equal(t, scraper.BreakerOpen, statuses["kompas"].State)
+	assert.Equal(t, "1m0s", statuses["kompas"].RetryIn)
+}
@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akhmadreiza/gober/models"
+)
+
+// MaxPageLimit caps the limit a client can request per page.
+const MaxPageLimit = 100
+
+// maxUpstreamPages caps how many upstream pages a single request may walk.
+const maxUpstreamPages = 10
+
+// Cursor points at the next article to return: item Offset of upstream page Page.
+type Cursor struct {
+	Page   int
+	Offset int
+}
+
+// FirstCursor starts at the first item of the first upstream page.
+var FirstCursor = Cursor{Page: 1}
+
+// Encode returns the opaque form handed to clients as next_cursor.
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", c.Page, c.Offset)))
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	page, offset, found := strings.Cut(string(raw), ".")
+	if !found {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	c := Cursor{}
+	if c.Page, err = strconv.Atoi(page); err != nil || c.Page < 1 {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	if c.Offset, err = strconv.Atoi(offset); err != nil || c.Offset < 0 {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// PageFetcher returns upstream page n (1-based) and whether further upstream
+// pages may exist after it.
+type PageFetcher func(page int) (articles []models.Article, more bool, err error)
+
+// Page is one page of results plus where to continue from.
+type Page struct {
+	Articles []models.Article
+	Next     *Cursor
+}
+
+// HasMore reports whether a following page may exist.
+func (p Page) HasMore() bool {
+	return p.Next != nil
+}
+
+// Paginate collects up to limit articles starting at from, fetching as many
+// upstream pages as needed. A limit of 0 returns the rest of the upstream page
+// the cursor points into. Errors are only returned when nothing was collected.
+func Paginate(fetch PageFetcher, from Cursor, limit int) (Page, error) {
+	result := Page{Articles: []models.Article{}}
+	cursor := from
+
+	for fetched := 0; fetched < maxUpstreamPages; fetched++ {
+		articles, more, err := fetch(cursor.Page)
+		if err != nil {
+			if len(result.Articles) == 0 {
+				return Page{}, err
+			}
+			// Keep what we have and let the client retry from here.
+			result.Next = &cursor
+			return result, nil
+		}
+
+		if cursor.Offset >= len(articles) {
+			// A page can be empty while more follow, e.g. when a time filter
+			// dropped all of it.
+			if !more {
+				return result, nil
+			}
+			cursor = Cursor{Page: cursor.Page + 1, Offset: cursor.Offset - len(articles)}
+			continue
+		}
+
+		remaining := articles[cursor.Offset:]
+		take := len(remaining)
+		if limit > 0 && take > limit-len(result.Articles) {
+			take = limit - len(result.Articles)
+		}
+		result.Articles = append(result.Articles, remaining[:take]...)
+
+		if take < len(remaining) {
+			result.Next = &Cursor{Page: cursor.Page, Offset: cursor.Offset + take}
+			return result, nil
+		}
+		if !more {
+			return result, nil
+		}
+		cursor = Cursor{Page: cursor.Page + 1}
+		if limit == 0 || len(result.Articles) >= limit {
+			result.Next = &cursor
+			return result, nil
+		}
+	}
+
+	result.Next = &cursor
+	return result, nil
+}
+
+// SinglePage adapts an already complete list to a PageFetcher, so it can be
+// paginated by offset.
+func SinglePage(articles []models.Article) PageFetcher {
+	return func(page int) ([]models.Article, bool, error) {
+		if page > 1 {
+			return nil, false, nil
+		}
+		return articles, false, nil
+	}
+}
@@ -0,0 +1,129 @@
+package scraper_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/stretchr/testify/assert"
+)
+
+// upstream simulates a search with pages of pageSize articles, totalPages deep.
+func upstream(pageSize, totalPages int, calls *[]int) scraper.PageFetcher {
+	return func(page int) ([]models.Article, bool, error) {
+		*calls = append(*calls, page)
+		if page > totalPages {
+			return nil, false, nil
+		}
+		var articles []models.Article
+		for i := 0; i < pageSize; i++ {
+			articles = append(articles, models.Article{Title: fmt.Sprintf("p%d-%d", page, i)})
+		}
+		return articles, true, nil
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := scraper.Cursor{Page: 3, Offset: 7}
+	decoded, err := scraper.DecodeCursor(c.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, c, decoded)
+
+	_, err = scraper.DecodeCursor("not a cursor")
+	assert.Error(t, err)
+	_, err = scraper.DecodeCursor(scraper.Cursor{Page: 0}.Encode())
+	assert.Error(t, err)
+}
+
+func TestPaginateWithoutLimitReturnsOneUpstreamPage(t *testing.T) {
+	var calls []int
+	page, err := scraper.Paginate(upstream(3, 5, &calls), scraper.FirstCursor, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p1-0", "p1-1", "p1-2"}, titlesOf(page.Articles))
+	assert.Equal(t, &scraper.Cursor{Page: 2}, page.Next)
+	assert.Equal(t, []int{1}, calls)
+}
+
+func TestPaginateFetchesMoreUpstreamPages(t *testing.T) {
+	var calls []int
+	page, err := scraper.Paginate(upstream(3, 5, &calls), scraper.Cursor{Page: 1, Offset: 2}, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p1-2", "p2-0", "p2-1", "p2-2", "p3-0"}, titlesOf(page.Articles))
+	assert.Equal(t, &scraper.Cursor{Page: 3, Offset: 1}, page.Next)
+	assert.Equal(t, []int{1, 2, 3}, calls)
+}
+
+func TestPaginateOffsetSkipsWholePages(t *testing.T) {
+	var calls []int
+	page, err := scraper.Paginate(upstream(3, 5, &calls), scraper.Cursor{Page: 1, Offset: 7}, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p3-1", "p3-2"}, titlesOf(page.Articles))
+	assert.True(t, page.HasMore())
+}
+
+func TestPaginateEndOfResults(t *testing.T) {
+	var calls []int
+	page, err := scraper.Paginate(upstream(3, 2, &calls), scraper.Cursor{Page: 2, Offset: 1}, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p2-1", "p2-2"}, titlesOf(page.Articles))
+	assert.False(t, page.HasMore())
+}
+
+func TestPaginateUpstreamErrors(t *testing.T) {
+	failing := func(page int) ([]models.Article, bool, error) {
+		if page == 1 {
+			return []models.Article{{Title: "a"}}, true, nil
+		}
+		return nil, false, errors.New("error: status code 502")
+	}
+
+	_, err := scraper.Paginate(failing, scraper.Cursor{Page: 2}, 5)
+	assert.EqualError(t, err, "error: status code 502")
+
+	page, err := scraper.Paginate(failing, scraper.FirstCursor, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, titlesOf(page.Articles))
+	assert.Equal(t, &scraper.Cursor{Page: 2}, page.Next)
+}
+
+func TestPaginateSinglePage(t *testing.T) {
+	articles := []models.Article{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+
+	page, _ := scraper.Paginate(scraper.SinglePage(articles), scraper.FirstCursor, 0)
+	assert.Equal(t, 3, len(page.Articles))
+	assert.False(t, page.HasMore())
+
+	page, _ = scraper.Paginate(scraper.SinglePage(articles), scraper.FirstCursor, 2)
+	assert.Equal(t, []string{"a", "b"}, titlesOf(page.Articles))
+	assert.Equal(t, &scraper.Cursor{Page: 1, Offset: 2}, page.Next)
+
+	page, _ = scraper.Paginate(scraper.SinglePage(articles), *page.Next, 2)
+	assert.Equal(t, []string{"c"}, titlesOf(page.Articles))
+	assert.False(t, page.HasMore())
+}
+
+func TestPaginateSkipsEmptyUpstreamPages(t *testing.T) {
+	// Page 2 was emptied by a time filter but page 3 still has results.
+	filtered := func(page int) ([]models.Article, bool, error) {
+		switch page {
+		case 1:
+			return []models.Article{{Title: "a"}}, true, nil
+		case 2:
+			return []models.Article{}, true, nil
+		case 3:
+			return []models.Article{{Title: "b"}, {Title: "c"}}, false, nil
+		}
+		return nil, false, nil
+	}
+
+	page, err := scraper.Paginate(filtered, scraper.FirstCursor, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, titlesOf(page.Articles))
+	assert.Equal(t, &scraper.Cursor{Page: 3, Offset: 1}, page.Next)
+}
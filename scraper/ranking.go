@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/akhmadreiza/gober/models"
+)
+
+// RankWeights controls how the merged popular feed is ordered. Each signal is
+// normalized to [0, 1] before weighting.
+type RankWeights struct {
+	// Recency favours recently published articles (halves every RecencyHalfLife).
+	Recency float64
+	// Position favours articles near the top of their own source's list.
+	Position float64
+	// Coverage favours stories several sources are running at the same time.
+	Coverage float64
+}
+
+// DefaultRankWeights lets source position lead, with recency and cross-source
+// coverage as tie breakers.
+var DefaultRankWeights = RankWeights{Recency: 1, Position: 2, Coverage: 1}
+
+// RecencyHalfLife is the age at which an article's recency score halves.
+const RecencyHalfLife = 6 * time.Hour
+
+// similarTitleThreshold is the word overlap (Jaccard) at which two titles
+// from different sources are considered the same story.
+const similarTitleThreshold = 0.5
+
+// ParseRankWeights parses "recency=1,position=2,coverage=0.5". Omitted keys
+// keep their DefaultRankWeights value.
+func ParseRankWeights(s string) (RankWeights, error) {
+	weights := DefaultRankWeights
+	if strings.TrimSpace(s) == "" {
+		return weights, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return weights, fmt.Errorf("invalid rank weight %q", pair)
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 {
+			return weights, fmt.Errorf("invalid rank weight %q", pair)
+		}
+		switch strings.TrimSpace(key) {
+		case "recency":
+			weights.Recency = w
+		case "position":
+			weights.Position = w
+		case "coverage":
+			weights.Coverage = w
+		default:
+			return weights, fmt.Errorf("unknown rank weight %q", key)
+		}
+	}
+	return weights, nil
+}
+
+type rankedArticle struct {
+	article  models.Article
+	source   int
+	position float64
+	recency  float64
+	words    map[string]bool
+	coverage float64
+	score    float64
+}
+
+// RankPopular merges per-source popular lists into one feed ordered by the
+// weighted score of recency, in-source position and cross-source coverage.
+func RankPopular(lists [][]models.Article, weights RankWeights, now time.Time) []models.Article {
+	var ranked []*rankedArticle
+	sourcesWithArticles := 0
+	for source, list := range lists {
+		if len(list) > 0 {
+			sourcesWithArticles++
+		}
+		for pos, article := range list {
+			r := &rankedArticle{
+				article:  article,
+				source:   source,
+				position: 1 - float64(pos)/float64(len(list)),
+				words:    titleWords(article.Title),
+			}
+			if published, ok := PublishTime(article); ok {
+				age := now.Sub(published)
+				if age < 0 {
+					age = 0
+				}
+				r.recency = math.Pow(0.5, age.Hours()/RecencyHalfLife.Hours())
+			}
+			ranked = append(ranked, r)
+		}
+	}
+
+	if sourcesWithArticles > 1 {
+		for _, r := range ranked {
+			covering := map[int]bool{r.source: true}
+			for _, other := range ranked {
+				if other.source != r.source && !covering[other.source] && jaccard(r.words, other.words) >= similarTitleThreshold {
+					covering[other.source] = true
+				}
+			}
+			r.coverage = float64(len(covering)-1) / float64(sourcesWithArticles-1)
+		}
+	}
+
+	for _, r := range ranked {
+		r.score = weights.Recency*r.recency + weights.Position*r.position + weights.Coverage*r.coverage
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	articles := make([]models.Article, len(ranked))
+	for i, r := range ranked {
+		articles[i] = r.article
+	}
+	return articles
+}
+
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 2 {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,87 @@
+package scraper_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func titlesOf(articles []models.Article) []string {
+	titles := []string{}
+	for _, a := range articles {
+		titles = append(titles, a.Title)
+	}
+	return titles
+}
+
+func TestParseRankWeights(t *testing.T) {
+	weights, err := scraper.ParseRankWeights("")
+	assert.NoError(t, err)
+	assert.Equal(t, scraper.DefaultRankWeights, weights)
+
+	weights, err = scraper.ParseRankWeights("recency=0.5, coverage=3")
+	assert.NoError(t, err)
+	assert.Equal(t, scraper.RankWeights{Recency: 0.5, Position: scraper.DefaultRankWeights.Position, Coverage: 3}, weights)
+
+	_, err = scraper.ParseRankWeights("freshness=1")
+	assert.EqualError(t, err, `unknown rank weight "freshness"`)
+	_, err = scraper.ParseRankWeights("recency=-1")
+	assert.Error(t, err)
+	_, err = scraper.ParseRankWeights("recency")
+	assert.Error(t, err)
+}
+
+func TestRankPopularInterleavesByPosition(t *testing.T) {
+	lists := [][]models.Article{
+		{{Title: "detik satu"}, {Title: "detik dua"}},
+		{{Title: "kompas satu"}, {Title: "kompas dua"}},
+	}
+
+	ranked := scraper.RankPopular(lists, scraper.RankWeights{Position: 1}, time.Now())
+
+	assert.Equal(t, []string{"detik satu", "kompas satu", "detik dua", "kompas dua"}, titlesOf(ranked))
+}
+
+func TestRankPopularRecency(t *testing.T) {
+	now := time.Date(2024, 12, 2, 12, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+	lists := [][]models.Article{
+		{{Title: "lama", Date: "Minggu, 01 Des 2024 12:00 WIB"}},
+		{{Title: "baru", Date: "02/12/2024, 11:00 WIB"}},
+		{{Title: "tanpa tanggal", Date: "-"}},
+	}
+
+	ranked := scraper.RankPopular(lists, scraper.RankWeights{Recency: 1}, now)
+
+	assert.Equal(t, []string{"baru", "lama", "tanpa tanggal"}, titlesOf(ranked))
+}
+
+func TestRankPopularCoverage(t *testing.T) {
+	lists := [][]models.Article{
+		{{Title: "Gempa Magnitudo 5 Guncang Garut"}, {Title: "Harga Emas Naik Lagi"}},
+		{{Title: "Timnas Menang Telak"}, {Title: "Gempa Magnitudo 5 Guncang Garut Jawa Barat"}},
+		{{Title: "Resep Rendang"}},
+	}
+
+	ranked := scraper.RankPopular(lists, scraper.RankWeights{Coverage: 1}, time.Now())
+
+	assert.Equal(t, "Gempa Magnitudo 5 Guncang Garut", ranked[0].Title)
+	assert.Equal(t, "Gempa Magnitudo 5 Guncang Garut Jawa Barat", ranked[1].Title)
+}
+
+func TestPopularAllTagsSourceAndDedupes(t *testing.T) {
+	registry := newAggregateRegistry(t)
+
+	result := registry.PopularAll(context.Background(), []string{"detik", "kompas", "tribun"}, utils.LinkBuilder{}, scraper.DefaultRankWeights)
+
+	assert.True(t, result.Succeeded())
+	assert.False(t, result.Complete())
+	assert.Equal(t, 4, len(result.Articles))
+	for _, a := range result.Articles {
+		assert.NotEmpty(t, a.Source)
+	}
+}
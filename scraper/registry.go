@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes which NewsScraper operations a source actually supports.
+type Capabilities struct {
+	Search  bool `json:"search"`
+	Popular bool `json:"popular"`
+	Detail  bool `json:"detail"`
+	// Index means the scraper also implements Indexer.
+	Index bool `json:"index"`
+}
+
+// Operation names one of the NewsScraper operations.
+type Operation string
+
+const (
+	OpSearch  Operation = "search"
+	OpPopular Operation = "popular"
+	OpDetail  Operation = "detail"
+	OpIndex   Operation = "index"
+)
+
+// Supports reports whether op is enabled in c.
+func (c Capabilities) Supports(op Operation) bool {
+	switch op {
+	case OpSearch:
+		return c.Search
+	case OpPopular:
+		return c.Popular
+	case OpDetail:
+		return c.Detail
+	case OpIndex:
+		return c.Index
+	}
+	return false
+}
+
+// Source is a registered news site: its query name, display metadata,
+// the hostnames Gober may fetch for it and the scraper that handles it.
+type Source struct {
+	Name         string       `json:"name"`
+	DisplayName  string       `json:"display_name"`
+	Homepage     string       `json:"homepage"`
+	Hosts        []string     `json:"hosts"`
+	Capabilities Capabilities `json:"capabilities"`
+	Scraper      NewsScraper  `json:"-"`
+}
+
+// MatchesHost reports whether host is one of the source's hostnames or a subdomain of one.
+func (s Source) MatchesHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range s.Hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds every known Source. Routing, the SSRF allow-list and the
+// /sources endpoint are all derived from it.
+type Registry struct {
+	mu      sync.RWMutex
+	sources map[string]Source
+	order   []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register adds a source. Names must be unique and every source needs a scraper.
+func (r *Registry) Register(src Source) error {
+	if src.Name == "" {
+		return fmt.Errorf("source name is empty")
+	}
+	if src.Scraper == nil {
+		return fmt.Errorf("source %v has no scraper", src.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.sources[src.Name]; exists {
+		return fmt.Errorf("source %v already registered", src.Name)
+	}
+	r.sources[src.Name] = src
+	r.order = append(r.order, src.Name)
+	return nil
+}
+
+// Source returns the registered source by name.
+func (r *Registry) Source(name string) (Source, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	src, ok := r.sources[name]
+	return src, ok
+}
+
+// Sources returns all registered sources in registration order.
+func (r *Registry) Sources() []Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Source, 0, len(r.order))
+	for _, name := range r.order {
+		list = append(list, r.sources[name])
+	}
+	return list
+}
+
+// SourceForURL returns the source owning rawURL's host. Only absolute
+// http(s) URLs are considered.
+func (r *Registry) SourceForURL(rawURL string) (Source, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return Source{}, false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return Source{}, false
+	}
+	host := parsed.Hostname()
+	for _, src := range r.Sources() {
+		if src.MatchesHost(host) {
+			return src, true
+		}
+	}
+	return Source{}, false
+}
+
+// IsAllowedURL reports whether rawURL targets a registered news domain.
+func (r *Registry) IsAllowedURL(rawURL string) bool {
+	_, ok := r.SourceForURL(rawURL)
+	return ok
+}
+
+// Decorate replaces every registered scraper with wrap(src), e.g. to archive
+// what each source returns.
+func (r *Registry) Decorate(wrap func(src Source) NewsScraper) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, src := range r.sources {
+		src.Scraper = wrap(src)
+		r.sources[name] = src
+	}
+}
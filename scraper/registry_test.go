@@ -0,0 +1,82 @@
+package scraper_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/scraper"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubScraper struct{}
+
+func (stubScraper) Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error) {
+	return nil, nil
+}
+
+func (stubScraper) Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error) {
+	return nil, nil
+}
+
+func (stubScraper) Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error) {
+	return models.Article{}, nil
+}
+
+func newTestRegistry(t *testing.T) *scraper.Registry {
+	registry := scraper.NewRegistry()
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "detik",
+		Hosts:        []string{"detik.com"},
+		Capabilities: scraper.Capabilities{Search: true, Popular: true, Detail: true},
+		Scraper:      stubScraper{},
+	}))
+	assert.NoError(t, registry.Register(scraper.Source{
+		Name:         "kompas",
+		Hosts:        []string{"kompas.com"},
+		Capabilities: scraper.Capabilities{Popular: true, Detail: true},
+		Scraper:      stubScraper{},
+	}))
+	return registry
+}
+
+func TestRegistryRejectsDuplicateAndInvalidSources(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	assert.EqualError(t, registry.Register(scraper.Source{Name: "detik", Scraper: stubScraper{}}), "source detik already registered")
+	assert.EqualError(t, registry.Register(scraper.Source{Name: "tribun"}), "source tribun has no scraper")
+	assert.EqualError(t, registry.Register(scraper.Source{Scraper: stubScraper{}}), "source name is empty")
+}
+
+func TestRegistryLookup(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	_, ok := registry.Source("tribun")
+	assert.False(t, ok)
+
+	sources := registry.Sources()
+	assert.Equal(t, 2, len(sources))
+	assert.Equal(t, "detik", sources[0].Name)
+	assert.Equal(t, "kompas", sources[1].Name)
+
+	kompas, ok := registry.Source("kompas")
+	assert.True(t, ok)
+	assert.False(t, kompas.Capabilities.Supports(scraper.OpSearch))
+	assert.True(t, kompas.Capabilities.Supports(scraper.OpPopular))
+}
+
+func TestRegistryIsAllowedURL(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	assert.True(t, registry.IsAllowedURL("https://news.detik.com/berita/d-7666179/some-article"))
+	assert.True(t, registry.IsAllowedURL("https://kompas.com/read/2024/01/01/some-article"))
+	assert.False(t, registry.IsAllowedURL("https://evildetik.com/"))
+	assert.False(t, registry.IsAllowedURL("ftp://detik.com/file"))
+	assert.False(t, registry.IsAllowedURL("/relative/path"))
+	assert.False(t, registry.IsAllowedURL("http://127.0.0.1:8080/health"))
+
+	src, ok := registry.SourceForURL("https://nasional.kompas.com/read/2024/01/01/some-article")
+	assert.True(t, ok)
+	assert.Equal(t, "kompas", src.Name)
+}
@@ -1,12 +1,23 @@
 package scraper
 
 import (
+	"context"
+
 	"github.com/akhmadreiza/gober/models"
-	"github.com/gin-gonic/gin"
+	"github.com/akhmadreiza/gober/utils"
 )
 
+// NewsScraper fetches articles from a single news site. Every call honors
+// ctx cancellation and deadlines; links controls how article URLs returned
+// to clients are built. Search returns one upstream result page (1-based).
 type NewsScraper interface {
-	Search(keyword string, ginContext *gin.Context) ([]models.Article, error)
-	Popular(ginContext *gin.Context) ([]models.Article, error)
-	Detail(url string, ginContext *gin.Context) (models.Article, error)
+	Search(ctx context.Context, keyword string, page int, links utils.LinkBuilder) ([]models.Article, error)
+	Popular(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error)
+	Detail(ctx context.Context, url string, links utils.LinkBuilder) (models.Article, error)
+}
+
+// Indexer is implemented by scrapers that can list a site's latest articles
+// from its index page. Sources advertise it with Capabilities.Index.
+type Indexer interface {
+	Latest(ctx context.Context, links utils.LinkBuilder) ([]models.Article, error)
 }
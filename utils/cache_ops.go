@@ -1,8 +1,144 @@
 package utils
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
 
 type CacheOps interface {
 	Get(key string) (interface{}, bool)
 	Set(key string, value interface{}, ttl time.Duration)
+	// Fetch returns the cached value for key, calling load on a miss. Concurrent
+	// misses on the same key share one load, and an entry that expired only
+	// recently is served as is while a single background load refreshes it.
+	Fetch(ctx context.Context, key string, load Loader) (interface{}, error)
+}
+
+// Loader produces the value for a cache key and how long it stays fresh. A
+// zero ttl returns the value without caching it, e.g. for an empty list.
+type Loader func(ctx context.Context) (value interface{}, ttl time.Duration, err error)
+
+// FetchAs is CacheOps.Fetch for values of a known type. A cached value of
+// another type is treated as a miss: it is loaded again, through ctx's load
+// wrappers, and overwritten.
+func FetchAs[T any](ctx context.Context, cache CacheOps, key string, load func(ctx context.Context) (T, time.Duration, error)) (T, error) {
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		return load(ctx)
+	}
+	ctx = context.WithValue(ctx, cachedTypeKey{}, func(value interface{}) bool {
+		_, ok := value.(T)
+		return ok
+	})
+	value, err := cache.Fetch(ctx, key, loader)
+	typed, ok := value.(T)
+	if err == nil && !ok {
+		err = fmt.Errorf("cache entry %s holds %T, not %T", key, value, typed)
+	}
+	return typed, err
+}
+
+type cachedTypeKey struct{}
+
+// cachedType returns which cached values the FetchAs that set up ctx can
+// use; without one, any value will do.
+func cachedType(ctx context.Context) func(value interface{}) bool {
+	if accepts, ok := ctx.Value(cachedTypeKey{}).(func(interface{}) bool); ok {
+		return accepts
+	}
+	return func(interface{}) bool { return true }
+}
+
+type cacheRefreshKey struct{}
+
+// WithCacheRefresh marks ctx so Fetch skips cached entries and loads (and
+// re-caches) fresh ones, which is how the crawler keeps caches warm.
+func WithCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+// CacheRefresh reports whether ctx was marked by WithCacheRefresh.
+func CacheRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(cacheRefreshKey{}).(bool)
+	return refresh
+}
+
+type loadWrappersKey struct{}
+
+// WithLoadWrapper returns ctx with wrap added around every load Fetch runs
+// for it. Cache hits don't load, so decorators use this to act only on what
+// was actually fetched upstream. The first wrapper added is the outermost.
+func WithLoadWrapper(ctx context.Context, wrap func(Loader) Loader) context.Context {
+	wrappers, _ := ctx.Value(loadWrappersKey{}).([]func(Loader) Loader)
+	wrappers = append(wrappers[:len(wrappers):len(wrappers)], wrap)
+	return context.WithValue(ctx, loadWrappersKey{}, wrappers)
+}
+
+// wrapLoad applies ctx's load wrappers to load. The wrapped load runs without
+// them, so a Fetch nested inside it isn't wrapped twice.
+func wrapLoad(ctx context.Context, load Loader) Loader {
+	wrappers, _ := ctx.Value(loadWrappersKey{}).([]func(Loader) Loader)
+	if len(wrappers) == 0 {
+		return load
+	}
+	inner := load
+	load = func(ctx context.Context) (interface{}, time.Duration, error) {
+		return inner(context.WithValue(ctx, loadWrappersKey{}, nil))
+	}
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		load = wrappers[i](load)
+	}
+	return load
+}
+
+// entryState is what a cache lookup found for a key.
+type entryState int
+
+const (
+	entryMissing entryState = iota
+	entryFresh
+	entryStale
+)
+
+// readThrough is the Fetch logic shared by the cache backends: fresh entries
+// are returned, stale ones too while a single background load refreshes them,
+// and concurrent misses wait on one shared load, which is cancelled once all
+// of them have gone. set stores what load returns.
+func readThrough(ctx context.Context, flights *flightGroup, key string, lookup func() (interface{}, entryState), set func(key string, value interface{}, ttl time.Duration), load Loader) (interface{}, error) {
+	load = wrapLoad(ctx, load)
+	accepts := cachedType(ctx)
+	// a Fetch nested in the load checks its own values
+	ctx = context.WithValue(ctx, cachedTypeKey{}, nil)
+	run := func(ctx context.Context) (interface{}, error) {
+		value, ttl, err := load(ctx)
+		if err == nil && ttl > 0 {
+			set(key, value, ttl)
+		}
+		return value, err
+	}
+
+	if !CacheRefresh(ctx) {
+		value, state := lookup()
+		if state != entryMissing && !accepts(value) {
+			log.Printf("[Cache] %s holds a %T, reloading it", key, value)
+			state = entryMissing
+		}
+		switch state {
+		case entryFresh:
+			log.Printf("[Cache] success getting cache %s", key)
+			return value, nil
+		case entryStale:
+			log.Printf("[Cache] serving stale %s while refreshing", key)
+			// nobody waits on the refresh, so it must not be cancelled
+			flights.start(ctx, key, true, run)
+			return value, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		// don't start a load nobody is left to wait for
+		return nil, err
+	}
+	return flights.wait(ctx, flights.start(ctx, key, false, run))
 }
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"reflect"
+	"time"
+)
+
+// approxSize estimates the memory held by a cached value: string and slice
+// contents plus fixed-size fields, following pointers once. It is meant for
+// budgeting, not accounting, and ignores map and allocator overhead.
+func approxSize(value interface{}) int64 {
+	if value == nil {
+		return 0
+	}
+	return sizeOf(reflect.ValueOf(value), 0)
+}
+
+// maxSizeDepth stops runaway recursion on cyclic or deeply nested values.
+const maxSizeDepth = 8
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func sizeOf(v reflect.Value, depth int) int64 {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len()) + 16
+	case reflect.Slice:
+		if v.IsNil() {
+			return 24
+		}
+		size := int64(24)
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		size := int64(0)
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		size := int64(48)
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 8
+		}
+		return 8 + sizeOf(v.Elem(), depth+1)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return int64(v.Type().Size())
+		}
+		size := int64(0)
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOf(v.Field(i), depth+1)
+		}
+		return size
+	}
+	return int64(v.Type().Size())
+}
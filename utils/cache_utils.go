@@ -1,58 +1,209 @@
 package utils
 
 import (
+	"container/list"
+	"context"
 	"log"
 	"sync"
 	"time"
 )
 
+// DefaultStaleFor is how long after expiry Fetch may still serve an entry
+// while it is being refreshed.
+const DefaultStaleFor = 10 * time.Minute
+
+// Default cache bounds: plenty for every source's popular lists plus a few
+// thousand article details.
+const (
+	DefaultMaxEntries = 5000
+	DefaultMaxBytes   = 64 << 20
+)
+
 type CacheItems struct {
 	Data      interface{}
 	ExpiresAt time.Time
+	// Size is the approximate memory the entry holds, in bytes.
+	Size int64
+
+	key string
+}
+
+// CacheStats are the cache's counters since it was created, plus its
+// current size.
+type CacheStats struct {
+	Hits        int64 `json:"hits"`
+	StaleHits   int64 `json:"stale_hits"`
+	Misses      int64 `json:"misses"`
+	Evictions   int64 `json:"evictions"`
+	Expirations int64 `json:"expirations"`
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
 }
 
+// Cache is an in-memory LRU cache bounded by entry count and approximate
+// byte size. Entries past their stale window are removed lazily on access
+// and by the janitor (see StartJanitor).
 type Cache struct {
-	items map[string]CacheItems
-	mu    sync.RWMutex
+	items map[string]*list.Element
+	// lru holds *CacheItems, most recently used first.
+	lru   *list.List
+	bytes int64
+	stats CacheStats
+	mu    sync.Mutex
+	// StaleFor is the stale-while-revalidate window past ExpiresAt.
+	StaleFor time.Duration
+	// MaxEntries and MaxBytes bound the cache; zero means unbounded.
+	MaxEntries int
+	MaxBytes   int64
+	flights    flightGroup
 }
 
 func NewCache() *Cache {
-	return &Cache{items: make(map[string]CacheItems)}
+	return &Cache{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		StaleFor:   DefaultStaleFor,
+		MaxEntries: DefaultMaxEntries,
+		MaxBytes:   DefaultMaxBytes,
+	}
 }
 
 func (c *Cache) Get(key string) (interface{}, bool) {
 	log.Printf("[Cache] getting cache")
-	c.mu.RLock()
-	item, found := c.items[key]
-	c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if !found {
+	item, fresh := c.lookup(key, time.Now())
+	if item == nil {
 		log.Printf("[Cache] cache key not found")
+		c.stats.Misses++
 		return nil, false
 	}
-
-	if time.Now().After(item.ExpiresAt) {
+	if !fresh {
 		log.Printf("[Cache] cache is expired")
-		c.mu.Lock()
-		// Re-check after write lock: another goroutine may have Set a fresh value.
-		if current, ok := c.items[key]; ok && time.Now().After(current.ExpiresAt) {
-			delete(c.items, key)
-		}
-		c.mu.Unlock()
+		c.stats.Misses++
 		return nil, false
 	}
 
 	log.Printf("[Cache] success getting cache")
+	c.stats.Hits++
 	return item.Data, true
 }
 
 func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
 	log.Printf("[Cache] setting cache")
+	size := int64(len(key)) + approxSize(value)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.items[key] = CacheItems{
-		Data:      value,
-		ExpiresAt: time.Now().Add(ttl),
+	if c.MaxBytes > 0 && size > c.MaxBytes {
+		log.Printf("[Cache] %s is larger than the cache (%d bytes), not caching", key, size)
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	item := &CacheItems{Data: value, ExpiresAt: time.Now().Add(ttl), Size: size, key: key}
+	c.items[key] = c.lru.PushFront(item)
+	c.bytes += size
+
+	for c.lru.Len() > 1 && ((c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries) || (c.MaxBytes > 0 && c.bytes > c.MaxBytes)) {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
 	}
 	log.Printf("[Cache] success setting cache")
 }
+
+func (c *Cache) Fetch(ctx context.Context, key string, load Loader) (interface{}, error) {
+	lookup := func() (interface{}, entryState) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		item, fresh := c.lookup(key, time.Now())
+		switch {
+		case item != nil && fresh:
+			c.stats.Hits++
+			return item.Data, entryFresh
+		case item != nil:
+			c.stats.StaleHits++
+			return item.Data, entryStale
+		}
+		c.stats.Misses++
+		return nil, entryMissing
+	}
+	return readThrough(ctx, &c.flights, key, lookup, c.Set, load)
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// StartJanitor removes entries past their stale window every interval until
+// the returned stop function is called.
+func (c *Cache) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if removed := c.removeExpired(now); removed > 0 {
+					log.Printf("[Cache] janitor removed %d expired entries", removed)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (c *Cache) removeExpired(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*CacheItems).ExpiresAt.Add(c.StaleFor)) {
+			c.remove(elem)
+			c.stats.Expirations++
+			removed++
+		}
+		elem = prev
+	}
+	return removed
+}
+
+// lookup returns the entry for key, marking it recently used, and whether it
+// is still fresh. Entries past their stale window are dropped and reported
+// as missing. c.mu must be held.
+func (c *Cache) lookup(key string, now time.Time) (*CacheItems, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*CacheItems)
+	if now.After(item.ExpiresAt.Add(c.StaleFor)) {
+		c.remove(elem)
+		c.stats.Expirations++
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return item, now.Before(item.ExpiresAt)
+}
+
+// remove drops elem from the cache. c.mu must be held.
+func (c *Cache) remove(elem *list.Element) {
+	item := c.lru.Remove(elem).(*CacheItems)
+	delete(c.items, item.key)
+	c.bytes -= item.Size
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"log"
 	"time"
 )
@@ -26,3 +27,14 @@ func (c *CacheMock) Set(key string, value interface{}, ttl time.Duration) {
 	}
 	c.Items = items
 }
+
+func (c *CacheMock) Fetch(ctx context.Context, key string, load Loader) (interface{}, error) {
+	if c.Items.Found {
+		return c.Items.Data, nil
+	}
+	value, ttl, err := load(ctx)
+	if err == nil && ttl > 0 {
+		c.Set(key, value, ttl)
+	}
+	return value, err
+}
@@ -0,0 +1,332 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLoader returns "v<n>" for its n-th call, blocking until release is
+// closed when one is given.
+func countingLoader(calls *atomic.Int32, release chan struct{}, ttl time.Duration) utils.Loader {
+	return func(ctx context.Context) (interface{}, time.Duration, error) {
+		n := calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		return "v" + string(rune('0'+n)), ttl, nil
+	}
+}
+
+func TestCacheFetchCoalescesMisses(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := countingLoader(&calls, release, time.Minute)
+
+	//do test
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.Fetch(context.Background(), "detik:popular", load)
+		}()
+	}
+	// let every caller join the flight before it completes
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	//assertions
+	assert.Equal(t, int32(1), calls.Load())
+	for _, result := range results {
+		assert.Equal(t, "v1", result)
+	}
+	cached, found := cache.Get("detik:popular")
+	assert.True(t, found)
+	assert.Equal(t, "v1", cached)
+}
+
+func TestCacheFetchServesStaleWhileRefreshing(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.Set("detik:popular", "old", -time.Second)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := countingLoader(&calls, release, time.Minute)
+
+	//do test
+	first, err := cache.Fetch(context.Background(), "detik:popular", load)
+	assert.Nil(t, err)
+	second, err := cache.Fetch(context.Background(), "detik:popular", load)
+	assert.Nil(t, err)
+	close(release)
+
+	//assertions
+	assert.Equal(t, "old", first)
+	assert.Equal(t, "old", second)
+	assert.Eventually(t, func() bool {
+		value, found := cache.Get("detik:popular")
+		return found && value == "v1"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCacheFetchTooStaleLoadsInline(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.StaleFor = time.Second
+	cache.Set("detik:popular", "old", -time.Minute)
+	var calls atomic.Int32
+
+	//do test
+	value, err := cache.Fetch(context.Background(), "detik:popular", countingLoader(&calls, nil, time.Minute))
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", value)
+}
+
+func TestCacheFetchDoesNotCacheErrorsOrZeroTTL(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	var calls atomic.Int32
+
+	//do test
+	_, err := cache.Fetch(context.Background(), "detik:popular", func(ctx context.Context) (interface{}, time.Duration, error) {
+		calls.Add(1)
+		return nil, time.Minute, errors.New("error: status code 503")
+	})
+	assert.EqualError(t, err, "error: status code 503")
+	value, err := cache.Fetch(context.Background(), "detik:popular", countingLoader(&calls, nil, 0))
+	assert.Nil(t, err)
+
+	//assertions
+	assert.Equal(t, "v2", value)
+	_, found := cache.Get("detik:popular")
+	assert.False(t, found)
+}
+
+func TestCacheFetchRefresh(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.Set("detik:popular", "old", time.Minute)
+	var calls atomic.Int32
+
+	//do test
+	value, err := cache.Fetch(utils.WithCacheRefresh(context.Background()), "detik:popular", countingLoader(&calls, nil, time.Minute))
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", value)
+	cached, _ := cache.Get("detik:popular")
+	assert.Equal(t, "v1", cached)
+}
+
+func TestCacheFetchCallerCancelled(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cancelled := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, time.Duration, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, 0, ctx.Err()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	//do test
+	_, err := cache.Fetch(ctx, "detik:popular", load)
+
+	//assertions
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// the last caller leaving cancels the upstream load
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("load was not cancelled")
+	}
+	_, found := cache.Get("detik:popular")
+	assert.False(t, found)
+}
+
+func TestCacheFetchLoadOutlivesOneCaller(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := countingLoader(&calls, release, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	//do test
+	done := make(chan interface{})
+	go func() {
+		value, _ := cache.Fetch(context.Background(), "detik:popular", load)
+		done <- value
+	}()
+	// let the patient caller start the flight before the other one joins
+	time.Sleep(20 * time.Millisecond)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := cache.Fetch(ctx, "detik:popular", load)
+	close(release)
+
+	//assertions
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "v1", <-done)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCacheFetchLoadWrappers(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	var calls atomic.Int32
+	var order []string
+	wrapper := func(name string) func(utils.Loader) utils.Loader {
+		return func(load utils.Loader) utils.Loader {
+			return func(ctx context.Context) (interface{}, time.Duration, error) {
+				order = append(order, name)
+				return load(ctx)
+			}
+		}
+	}
+	ctx := utils.WithLoadWrapper(utils.WithLoadWrapper(context.Background(), wrapper("outer")), wrapper("inner"))
+
+	//do test
+	loaded, err := cache.Fetch(ctx, "detik:popular", countingLoader(&calls, nil, time.Minute))
+	assert.Nil(t, err)
+	hit, err := cache.Fetch(ctx, "detik:popular", countingLoader(&calls, nil, time.Minute))
+	assert.Nil(t, err)
+
+	//assertions
+	assert.Equal(t, "v1", loaded)
+	assert.Equal(t, "v1", hit)
+	// the cache hit didn't go through the wrappers
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
+
+func TestFetchAs(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.Set("detik:popular", 42, time.Minute)
+	wrapped := 0
+	ctx := utils.WithLoadWrapper(context.Background(), func(load utils.Loader) utils.Loader {
+		return func(ctx context.Context) (interface{}, time.Duration, error) {
+			wrapped++
+			return load(ctx)
+		}
+	})
+
+	//do test
+	value, err := utils.FetchAs(ctx, cache, "detik:popular", func(ctx context.Context) (string, time.Duration, error) {
+		return "fresh", time.Minute, nil
+	})
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "fresh", value)
+	// a value of the wrong type is a miss: reloaded through the wrappers and replaced
+	assert.Equal(t, 1, wrapped)
+	cached, found := cache.Get("detik:popular")
+	assert.True(t, found)
+	assert.Equal(t, "fresh", cached)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.MaxEntries = 2
+	cache.Set("a", "1", time.Minute)
+	cache.Set("b", "2", time.Minute)
+
+	//do test
+	cache.Get("a")
+	cache.Set("c", "3", time.Minute)
+
+	//assertions
+	_, foundA := cache.Get("a")
+	_, foundB := cache.Get("b")
+	_, foundC := cache.Get("c")
+	assert.True(t, foundA)
+	assert.False(t, foundB)
+	assert.True(t, foundC)
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Entries)
+}
+
+func TestCacheByteBudget(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.MaxBytes = 1500
+	body := strings.Repeat("x", 400)
+
+	//do test
+	cache.Set("detik:1", models.Article{Content: body}, time.Minute)
+	cache.Set("detik:2", models.Article{Content: body}, time.Minute)
+	cache.Set("detik:3", models.Article{Content: body}, time.Minute)
+	cache.Set("huge", strings.Repeat("x", 2000), time.Minute)
+
+	//assertions
+	_, found := cache.Get("detik:1")
+	assert.False(t, found)
+	_, found = cache.Get("huge")
+	assert.False(t, found)
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.LessOrEqual(t, stats.Bytes, int64(1500))
+	assert.Greater(t, stats.Bytes, int64(1000))
+}
+
+func TestCacheJanitorRemovesExpired(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.StaleFor = 0
+	cache.Set("old", "1", -time.Second)
+	cache.Set("fresh", "2", time.Minute)
+
+	//do test
+	stop := cache.StartJanitor(time.Millisecond)
+	defer stop()
+
+	//assertions
+	assert.Eventually(t, func() bool {
+		return cache.Stats().Entries == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), cache.Stats().Expirations)
+}
+
+func TestCacheStats(t *testing.T) {
+	//prepare data
+	cache := utils.NewCache()
+	cache.Set("a", "1", time.Minute)
+	cache.Set("stale", "2", -time.Second)
+	load := func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "v", time.Minute, nil
+	}
+
+	//do test
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Fetch(context.Background(), "a", load)
+	cache.Fetch(context.Background(), "stale", load)
+
+	//assertions
+	stats := cache.Stats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.StaleHits)
+	assert.Equal(t, int64(1), stats.Misses)
+}
@@ -10,6 +10,7 @@ import (
 
 var detikImgWidth = regexp.MustCompile(`w=\d+`)
 var kompasDimension = regexp.MustCompile(`/\d+x\d+/`)
+var tribunThumbnail = regexp.MustCompile(`/thumbnails\d*/`)
 
 // EnhanceImageURL rewrites thumbnail CDN URLs to request a larger image.
 func EnhanceImageURL(imgURL string) string {
@@ -19,6 +20,9 @@ func EnhanceImageURL(imgURL string) string {
 	if strings.Contains(imgURL, "asset.kompas.com") {
 		return kompasDimension.ReplaceAllLiteralString(imgURL, "/460x306/")
 	}
+	if strings.Contains(imgURL, "tstatic.net") {
+		return tribunThumbnail.ReplaceAllLiteralString(imgURL, "/images/")
+	}
 	return imgURL
 }
 
@@ -39,7 +43,31 @@ func CleanContent(s *goquery.Selection, extraSelectors ...string) string {
 	return strings.TrimSpace(html)
 }
 
-// RewriteContentLinks rewrites internal news links (detik.com, kompas.com)
+// WithQueryParam returns rawURL with key set to value in its query, e.g. to
+// ask a site for the single-page version of an article. rawURL is returned
+// unchanged when it already has that value or can't be parsed.
+func WithQueryParam(rawURL, key, value string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := parsed.Query()
+	switch {
+	case query.Get(key) == value:
+		return rawURL
+	case query.Has(key):
+		query.Set(key, value)
+		parsed.RawQuery = query.Encode()
+	case parsed.RawQuery != "":
+		parsed.RawQuery += "&" + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	default:
+		parsed.RawQuery = url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	}
+	return parsed.String()
+}
+
+// RewriteContentLinks rewrites internal news links (detik.com, kompas.com,
+// tribunnews.com, cnnindonesia.com)
 // to point to Gober's own /detail route, keeping readers on the app.
 func RewriteContentLinks(s *goquery.Selection) {
 	s.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
@@ -53,22 +81,14 @@ func RewriteContentLinks(s *goquery.Selection) {
 		switch {
 		case strings.Contains(parsed.Host, "detik.com"):
 			source = "detik"
-			if !strings.Contains(href, "single=1") {
-				if parsed.RawQuery != "" {
-					href += "&single=1"
-				} else {
-					href += "?single=1"
-				}
-			}
+			href = WithQueryParam(href, "single", "1")
 		case strings.Contains(parsed.Host, "kompas.com"):
 			source = "kompas"
-			if !strings.Contains(href, "page=all") {
-				if parsed.RawQuery != "" {
-					href += "&page=all"
-				} else {
-					href += "?page=all"
-				}
-			}
+			href = WithQueryParam(href, "page", "all")
+		case strings.Contains(parsed.Host, "tribunnews.com"):
+			source = "tribun"
+		case strings.Contains(parsed.Host, "cnnindonesia.com"):
+			source = "ccnid"
 		default:
 			return
 		}
@@ -105,3 +105,18 @@ func TestCleanContentPreservesArticleMarkup(t *testing.T) {
 	assert.Contains(t, result, `<a href="https://example.com">A link</a>`)
 	assert.Contains(t, result, `<img src="https://example.com/image.jpg"/>`)
 }
+
+func TestRewriteContentLinksCNNIndonesia(t *testing.T) {
+	html := `<div>
+		<p>Baca juga: <a href="https://www.cnnindonesia.com/nasional/20241202101530-12-1172345/some-article" target="_blank">Some Article</a></p>
+	</div>`
+
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	sel := doc.Find("div").First()
+	utils.RewriteContentLinks(sel)
+
+	result, _ := sel.Html()
+	assert.Contains(t, result, `/detail?source=ccnid&amp;detailUrl=`)
+	assert.Contains(t, result, `some-article`)
+	assert.NotContains(t, result, `target=`)
+}
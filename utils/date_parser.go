@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var indonesianMonths = map[string]time.Month{
+	"jan": time.January, "januari": time.January,
+	"feb": time.February, "februari": time.February, "peb": time.February, "pebruari": time.February,
+	"mar": time.March, "maret": time.March,
+	"apr": time.April, "april": time.April,
+	"mei": time.May, "may": time.May,
+	"jun": time.June, "juni": time.June,
+	"jul": time.July, "juli": time.July,
+	"agu": time.August, "agt": time.August, "ags": time.August, "agustus": time.August, "aug": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"okt": time.October, "oktober": time.October, "oct": time.October,
+	"nov": time.November, "nopember": time.November, "november": time.November,
+	"des": time.December, "desember": time.December, "dec": time.December,
+}
+
+// Indonesian time zones. Fixed offsets, Indonesia has no daylight saving.
+var indonesianZones = map[string]*time.Location{
+	"WIB":  time.FixedZone("WIB", 7*60*60),
+	"WITA": time.FixedZone("WITA", 8*60*60),
+	"WIT":  time.FixedZone("WIT", 9*60*60),
+}
+
+var (
+	// "Minggu, 01 Des 2024 23:30 WIB", "Senin, 2 Desember 2024 10:15 WIB"
+	namedMonthDate = regexp.MustCompile(`(\d{1,2})\s+([A-Za-z]+)\s+(\d{4})(?:[,|\s-]+(?:pukul\s+)?(\d{1,2})[:.](\d{2}))?`)
+	// "02/01/2025, 10:15 WIB"
+	numericDate = regexp.MustCompile(`(\d{1,2})/(\d{1,2})/(\d{4})(?:[,|\s-]+(?:pukul\s+)?(\d{1,2})[:.](\d{2}))?`)
+	zoneSuffix  = regexp.MustCompile(`\b(WIB|WITA|WIT)\b`)
+)
+
+// ParseIndonesianTime parses the publish dates shown by Indonesian news
+// sites, with Indonesian (or English) month names and WIB/WITA/WIT zones.
+// Dates without a zone are assumed to be WIB.
+func ParseIndonesianTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+
+	loc := indonesianZones["WIB"]
+	if m := zoneSuffix.FindStringSubmatch(strings.ToUpper(raw)); m != nil {
+		loc = indonesianZones[m[1]]
+	}
+
+	var day, year, hour, minute int
+	var month time.Month
+	if m := namedMonthDate.FindStringSubmatch(raw); m != nil {
+		mon, ok := indonesianMonths[strings.ToLower(m[2])]
+		if !ok {
+			return time.Time{}, false
+		}
+		day, _ = strconv.Atoi(m[1])
+		month = mon
+		year, _ = strconv.Atoi(m[3])
+		hour, minute = atoiOrZero(m[4]), atoiOrZero(m[5])
+	} else if m := numericDate.FindStringSubmatch(raw); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		mon, _ := strconv.Atoi(m[2])
+		month = time.Month(mon)
+		year, _ = strconv.Atoi(m[3])
+		hour, minute = atoiOrZero(m[4]), atoiOrZero(m[5])
+	} else {
+		return time.Time{}, false
+	}
+
+	if month < time.January || month > time.December || day < 1 || day > 31 || hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	// time.Date normalizes overflow (31 Feb -> 3 Mar); treat that as invalid.
+	if t.Day() != day {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// PublishedAt parses raw with ParseIndonesianTime for models.Article.PublishedAt.
+func PublishedAt(raw string) *time.Time {
+	t, ok := ParseIndonesianTime(raw)
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
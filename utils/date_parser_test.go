@@ -0,0 +1,34 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIndonesianTime(t *testing.T) {
+	cases := map[string]string{
+		"Minggu, 01 Des 2024 23:30 WIB":      "2024-12-01T23:30:00+07:00",
+		"Senin, 2 Desember 2024 10:15 WIB":   "2024-12-02T10:15:00+07:00",
+		"02/01/2025, 10:15 WIB":              "2025-01-02T10:15:00+07:00",
+		"Kamis, 5 Sep 2024 08.05 WITA":       "2024-09-05T08:05:00+08:00",
+		"12 Agustus 2024, 19:00 WIT":         "2024-08-12T19:00:00+09:00",
+		"02/01/2025":                         "2025-01-02T00:00:00+07:00",
+		"Senin, 02 Des 2024 - 10:15 WIB":     "2024-12-02T10:15:00+07:00",
+		"Kompas.com - 02/12/2024, 10:15 WIB": "2024-12-02T10:15:00+07:00",
+	}
+	for raw, want := range cases {
+		got, ok := utils.ParseIndonesianTime(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, want, got.Format(time.RFC3339), raw)
+	}
+}
+
+func TestParseIndonesianTimeRejectsGarbage(t *testing.T) {
+	for _, raw := range []string{"", "-", "1 jam yang lalu", "31 Februari 2024 10:00 WIB", "13/13/2024", "02 Foo 2024"} {
+		_, ok := utils.ParseIndonesianTime(raw)
+		assert.False(t, ok, raw)
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimits is how hard Gober may hit one host.
+type HostLimits struct {
+	// MaxConcurrent caps requests in flight to the host; 0 means no cap.
+	MaxConcurrent int
+	// MinDelay is the least time between the starts of two requests.
+	MinDelay time.Duration
+}
+
+var DefaultHostLimits = HostLimits{MaxConcurrent: 4, MinDelay: 250 * time.Millisecond}
+
+// HostLimiter queues outbound requests so that each host sees at most its
+// HostLimits, however many users are waiting on it.
+type HostLimiter struct {
+	Default HostLimits
+	// Overrides applies to a host and its subdomains, e.g. "detik.com" also
+	// covers news.detik.com. The most specific entry wins.
+	Overrides map[string]HostLimits
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	slots chan struct{}
+
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+func NewHostLimiter(def HostLimits, overrides map[string]HostLimits) *HostLimiter {
+	return &HostLimiter{Default: def, Overrides: overrides, hosts: map[string]*hostState{}}
+}
+
+// Acquire blocks until a request to host may start, or ctx is done. The
+// returned release must be called once the request has finished.
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (release func(), err error) {
+	state := l.state(host)
+	release = func() {}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+			release = func() { <-state.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	for {
+		wait := state.reserve(time.Now())
+		if wait <= 0 {
+			return release, nil
+		}
+		// Another caller may book the slot meanwhile, so try again after.
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// RaiseMinDelay slows host down to at least one request per delay, e.g. for
+// a robots.txt Crawl-delay. It never speeds a host up.
+func (l *HostLimiter) RaiseMinDelay(host string, delay time.Duration) {
+	state := l.state(host)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if delay > state.delay {
+		state.delay = delay
+	}
+}
+
+func (l *HostLimiter) state(host string) *hostState {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.hosts[host]; ok {
+		return state
+	}
+	limits := l.limitsFor(host)
+	state := &hostState{delay: limits.MinDelay}
+	if limits.MaxConcurrent > 0 {
+		state.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	l.hosts[host] = state
+	return state
+}
+
+func (l *HostLimiter) limitsFor(host string) HostLimits {
+	for domain := host; domain != ""; {
+		if limits, ok := l.Overrides[domain]; ok {
+			return limits
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return l.Default
+}
+
+// reserve books a start for the host now if its delay has passed, and
+// otherwise returns how long until it has, booking nothing. A caller that
+// gives up waiting then leaves no gap behind.
+func (s *hostState) reserve(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Before(s.next) {
+		return s.next.Sub(now)
+	}
+	s.next = now.Add(s.delay)
+	return 0
+}
+
+// ParseHostLimits parses "default=4/250ms,detik.com=2/1s": a host (or
+// "default") mapped to its max concurrency and, optionally, minimum delay.
+func ParseHostLimits(s string) (HostLimits, map[string]HostLimits, error) {
+	def := DefaultHostLimits
+	overrides := map[string]HostLimits{}
+	if strings.TrimSpace(s) == "" {
+		return def, overrides, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		host, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return def, nil, fmt.Errorf("invalid host limit %q", pair)
+		}
+		concurrency, delay, _ := strings.Cut(strings.TrimSpace(value), "/")
+		limits := HostLimits{}
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 0 {
+			return def, nil, fmt.Errorf("invalid host limit %q", pair)
+		}
+		limits.MaxConcurrent = n
+		if delay != "" {
+			if limits.MinDelay, err = time.ParseDuration(delay); err != nil || limits.MinDelay < 0 {
+				return def, nil, fmt.Errorf("invalid host limit %q", pair)
+			}
+		}
+		if host = strings.ToLower(strings.TrimSpace(host)); host == "default" {
+			def = limits
+		} else {
+			overrides[host] = limits
+		}
+	}
+	return def, overrides, nil
+}
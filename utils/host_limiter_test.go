@@ -0,0 +1,125 @@
+package utils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiterCapsConcurrency(t *testing.T) {
+	//prepare data
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+	client := utils.NewHTTPClient()
+	client.Hosts = utils.NewHostLimiter(utils.HostLimits{MaxConcurrent: 2}, nil)
+	client.Robots = nil
+
+	//do test
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Get(context.Background(), server.URL)
+		}()
+	}
+	wg.Wait()
+
+	//assertions
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+	//prepare data
+	limiter := utils.NewHostLimiter(utils.HostLimits{MinDelay: 20 * time.Millisecond}, map[string]utils.HostLimits{
+		"detik.com": {MinDelay: 0},
+	})
+
+	//do test
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := limiter.Acquire(context.Background(), "kompas.com")
+		assert.Nil(t, err)
+		release()
+	}
+	spaced := time.Since(start)
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := limiter.Acquire(context.Background(), "news.detik.com")
+		assert.Nil(t, err)
+		release()
+	}
+
+	//assertions
+	assert.GreaterOrEqual(t, spaced, 60*time.Millisecond)
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestHostLimiterQueueRespectsContext(t *testing.T) {
+	//prepare data
+	limiter := utils.NewHostLimiter(utils.HostLimits{MaxConcurrent: 1}, nil)
+	release, err := limiter.Acquire(context.Background(), "detik.com")
+	assert.Nil(t, err)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	//do test
+	_, err = limiter.Acquire(ctx, "detik.com")
+
+	//assertions
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHostLimiterCancelledWaitKeepsSchedule(t *testing.T) {
+	//prepare data
+	limiter := utils.NewHostLimiter(utils.HostLimits{MinDelay: 50 * time.Millisecond}, nil)
+	release, err := limiter.Acquire(context.Background(), "detik.com")
+	assert.Nil(t, err)
+	release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	//do test
+	_, errCancelled := limiter.Acquire(ctx, "detik.com")
+	start := time.Now()
+	release, err = limiter.Acquire(context.Background(), "detik.com")
+	assert.Nil(t, err)
+	release()
+
+	//assertions
+	assert.ErrorIs(t, errCancelled, context.DeadlineExceeded)
+	// the next start is the first request's, not one after the cancelled wait
+	assert.Less(t, time.Since(start), 70*time.Millisecond)
+}
+
+func TestParseHostLimits(t *testing.T) {
+	//do test
+	def, overrides, err := utils.ParseHostLimits("default=8/100ms, detik.com=2/1s,kompas.com=3")
+	_, _, errBad := utils.ParseHostLimits("detik.com=two")
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, utils.HostLimits{MaxConcurrent: 8, MinDelay: 100 * time.Millisecond}, def)
+	assert.Equal(t, utils.HostLimits{MaxConcurrent: 2, MinDelay: time.Second}, overrides["detik.com"])
+	assert.Equal(t, utils.HostLimits{MaxConcurrent: 3}, overrides["kompas.com"])
+	assert.EqualError(t, errBad, `invalid host limit "detik.com=two"`)
+}
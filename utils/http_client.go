@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"context"
+
 	"github.com/akhmadreiza/gober/models"
 )
 
 type HTTPClient interface {
-	Get(url string) (models.ScraperResponse, error)
+	Get(ctx context.Context, url string) (models.ScraperResponse, error)
 }
@@ -1,12 +1,22 @@
 package utils
 
-import "github.com/akhmadreiza/gober/models"
+import (
+	"context"
 
+	"github.com/akhmadreiza/gober/models"
+)
+
+// HttpClientMock returns Response for every URL, unless the URL has its own
+// entry in Responses.
 type HttpClientMock struct {
-	Response models.ScraperResponse
-	Err      error
+	Response  models.ScraperResponse
+	Responses map[string]models.ScraperResponse
+	Err       error
 }
 
-func (m HttpClientMock) Get(url string) (models.ScraperResponse, error) {
+func (m HttpClientMock) Get(ctx context.Context, url string) (models.ScraperResponse, error) {
+	if resp, ok := m.Responses[url]; ok {
+		return resp, m.Err
+	}
 	return m.Response, m.Err
 }
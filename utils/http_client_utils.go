@@ -1,47 +1,316 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand/v2"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/akhmadreiza/gober/models"
+	"golang.org/x/net/html/charset"
 )
 
 type RealHTTPClient struct {
 	Client *http.Client
+	Retry  RetryPolicy
+	// Hosts paces requests per host; nil sends them as they come.
+	Hosts *HostLimiter
+	// Robots, when set, refuses URLs the site's robots.txt disallows and
+	// applies its Crawl-delay to Hosts.
+	Robots *RobotsPolicy
+	// MaxBodyBytes caps how much of a response is read; 0 means no cap.
+	MaxBodyBytes int64
+	// Pages keeps the validators and body of pages served with an ETag or
+	// Last-Modified, so refetching them is a conditional GET. nil disables it.
+	Pages *Cache
+}
+
+// DefaultMaxBodyBytes is well above the largest article or index page.
+const DefaultMaxBodyBytes = 5 << 20
+
+// Errors for upstream responses Gober refuses to parse.
+var (
+	ErrTooLarge = errors.New("response body too large")
+	ErrNotHTML  = errors.New("response is not HTML")
+)
+
+// StatusError is how the parsers report an upstream response other than 200.
+type StatusError struct {
+	Code int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("error: status code %d", e.Code)
+}
+
+// storedPage is what Pages keeps per URL.
+type storedPage struct {
+	ETag         string
+	LastModified string
+	Body         string
+}
+
+// Defaults for RealHTTPClient.Pages: validators outlive any cache entry
+// built from the page, and bodies are bounded by size.
+const (
+	DefaultPageTTL      = 24 * time.Hour
+	DefaultPageMaxBytes = 32 << 20
+)
+
+func NewPageStore() *Cache {
+	pages := NewCache()
+	pages.StaleFor = 0
+	pages.MaxBytes = DefaultPageMaxBytes
+	return pages
+}
+
+// RetryPolicy controls how Get retries network errors, 429 and 5xx
+// responses. Waits grow exponentially from BaseDelay up to MaxDelay with
+// jitter, unless the server sends Retry-After.
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts after the first; 0 disables retries.
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// Budget caps the time one Get may take across all its attempts, on top
+	// of the caller's context; 0 leaves it to the context.
+	Budget time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 2,
+	BaseDelay:  300 * time.Millisecond,
+	MaxDelay:   5 * time.Second,
+	Budget:     20 * time.Second,
 }
 
 func NewHTTPClient() *RealHTTPClient {
+	client := &http.Client{Timeout: 15 * time.Second}
 	return &RealHTTPClient{
-		Client: &http.Client{Timeout: 15 * time.Second},
+		Client:       client,
+		Retry:        DefaultRetryPolicy,
+		Hosts:        NewHostLimiter(DefaultHostLimits, nil),
+		Robots:       NewRobotsPolicy(client, nil),
+		Pages:        NewPageStore(),
+		MaxBodyBytes: DefaultMaxBodyBytes,
 	}
 }
 
 const userAgent = "Gober/1.0 (+https://github.com/akhmadreiza/gober)"
 
-func (h RealHTTPClient) Get(rawURL string) (sr models.ScraperResponse, err error) {
-	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+func (h RealHTTPClient) Get(ctx context.Context, rawURL string) (sr models.ScraperResponse, err error) {
+	if h.Retry.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Retry.Budget)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		var retryable bool
+		sr, retryAfter, retryable, err = h.attempt(ctx, rawURL)
+		if !retryable || attempt >= h.Retry.MaxRetries {
+			return sr, err
+		}
+
+		delay := retryAfter
+		if h.Retry.MaxDelay > 0 && delay > h.Retry.MaxDelay {
+			// The server wants a longer break than we are willing to wait.
+			return sr, err
+		}
+		if delay <= 0 {
+			delay = h.Retry.backoff(attempt)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Waiting would outlive the budget; report what we have.
+			return sr, err
+		}
+		reason := fmt.Sprintf("status code %d", sr.Status)
+		if err != nil {
+			reason = err.Error()
+		}
+		log.Printf("[HTTP] retrying %s in %s after %s", rawURL, delay.Round(time.Millisecond), reason)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = fmt.Errorf("failed to fetch URL: %w", ctx.Err())
+			}
+			return sr, err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes one request. It reports whether the outcome is worth
+// retrying and how long the server asked to wait before doing so.
+func (h RealHTTPClient) attempt(ctx context.Context, rawURL string) (models.ScraperResponse, time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 	if err != nil {
-		return models.ScraperResponse{}, fmt.Errorf("failed to create request: %w", err)
+		return models.ScraperResponse{}, 0, false, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("User-Agent", userAgent)
 
+	var stored storedPage
+	var haveStored bool
+	if h.Pages != nil {
+		if value, found := h.Pages.Get(rawURL); found {
+			stored, haveStored = value.(storedPage)
+		}
+	}
+	if haveStored {
+		if stored.ETag != "" {
+			req.Header.Set("If-None-Match", stored.ETag)
+		}
+		if stored.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stored.LastModified)
+		}
+	}
+
+	if h.Robots != nil {
+		crawlDelay, err := h.Robots.Check(ctx, req.URL)
+		if err != nil {
+			return models.ScraperResponse{}, 0, false, err
+		}
+		if crawlDelay > 0 && h.Hosts != nil {
+			h.Hosts.RaiseMinDelay(req.URL.Hostname(), crawlDelay)
+		}
+	}
+
+	if h.Hosts != nil {
+		release, err := h.Hosts.Acquire(ctx, req.URL.Hostname())
+		if err != nil {
+			return models.ScraperResponse{}, 0, false, fmt.Errorf("failed to fetch URL: %w", err)
+		}
+		defer release()
+	}
+
 	resp, err := h.Client.Do(req)
 	if err != nil {
-		return models.ScraperResponse{}, fmt.Errorf("failed to fetch URL: %w", err)
+		retryable := ctx.Err() == nil && !errors.Is(err, ErrForbiddenDestination)
+		return models.ScraperResponse{}, 0, retryable, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotModified && haveStored {
+		return models.ScraperResponse{
+			Body:        stored.Body,
+			Status:      http.StatusOK,
+			WebUrl:      rawURL,
+			NotModified: true,
+		}, 0, false, nil
+	}
+
+	body, err := h.readBody(resp, rawURL)
+	if errors.Is(err, ErrTooLarge) || errors.Is(err, ErrNotHTML) {
+		return models.ScraperResponse{}, 0, false, err
+	}
 	if err != nil {
-		return models.ScraperResponse{}, fmt.Errorf("failed to read response body: %w", err)
+		return models.ScraperResponse{}, 0, ctx.Err() == nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return models.ScraperResponse{
-		Body:   string(body),
+	sr := models.ScraperResponse{
+		Body:   body,
 		Status: resp.StatusCode,
 		WebUrl: rawURL,
-	}, nil
+	}
+	if h.Pages != nil && resp.StatusCode == http.StatusOK {
+		page := storedPage{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), Body: sr.Body}
+		if page.ETag != "" || page.LastModified != "" {
+			h.Pages.Set(rawURL, page, DefaultPageTTL)
+		}
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+	return sr, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), retryable, nil
+}
+
+// readBody reads at most MaxBodyBytes of resp. Successful responses must be
+// HTML, and are decoded to UTF-8 from the charset in their Content-Type or
+// <meta> tag.
+func (h RealHTTPClient) readBody(resp *http.Response, rawURL string) (string, error) {
+	if h.MaxBodyBytes > 0 && resp.ContentLength > h.MaxBodyBytes {
+		return "", fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrTooLarge, rawURL, resp.ContentLength, h.MaxBodyBytes)
+	}
+
+	reader := io.Reader(resp.Body)
+	if h.MaxBodyBytes > 0 {
+		reader = io.LimitReader(resp.Body, h.MaxBodyBytes+1)
+	}
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if h.MaxBodyBytes > 0 && int64(len(raw)) > h.MaxBodyBytes {
+		return "", fmt.Errorf("%w: %s is over the %d byte limit", ErrTooLarge, rawURL, h.MaxBodyBytes)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return string(raw), nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(raw)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !htmlMediaTypes[mediaType] {
+		return "", fmt.Errorf("%w: %s is %q", ErrNotHTML, rawURL, contentType)
+	}
+
+	encoding, name, _ := charset.DetermineEncoding(raw, contentType)
+	if name == "utf-8" {
+		return string(raw), nil
+	}
+	decoded, err := encoding.NewDecoder().Bytes(raw)
+	if err != nil {
+		return "", fmt.Errorf("decoding %s from %s: %w", rawURL, name, err)
+	}
+	return string(decoded), nil
+}
+
+var htmlMediaTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+}
+
+// backoff is the wait before retry number attempt+1: BaseDelay doubled per
+// attempt, capped at MaxDelay, then jittered down by up to half.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter reads a Retry-After header given either as seconds or as
+// an HTTP date. It returns 0 when the header is absent or unusable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
 }
@@ -0,0 +1,265 @@
+package utils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryingClient() *utils.RealHTTPClient {
+	client := utils.NewHTTPClient()
+	client.Retry = utils.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond, Budget: time.Second}
+	client.Hosts = nil
+	client.Robots = nil
+	return client
+}
+
+// flakyServer answers status for the first failures requests, then 200.
+func flakyServer(failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("<html>ok</html>"))
+	}))
+	return server, &calls
+}
+
+func TestGetRetriesServerErrors(t *testing.T) {
+	//prepare data
+	server, calls := flakyServer(2, http.StatusBadGateway, nil)
+	defer server.Close()
+
+	//do test
+	resp, err := newRetryingClient().Get(context.Background(), server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.Status)
+	assert.Equal(t, "<html>ok</html>", resp.Body)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGetGivesUpAfterMaxRetries(t *testing.T) {
+	//prepare data
+	server, calls := flakyServer(10, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	//do test
+	resp, err := newRetryingClient().Get(context.Background(), server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Status)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	//prepare data
+	server, calls := flakyServer(10, http.StatusNotFound, nil)
+	defer server.Close()
+
+	//do test
+	resp, err := newRetryingClient().Get(context.Background(), server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.Status)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGetHonoursRetryAfter(t *testing.T) {
+	//prepare data
+	server, calls := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}})
+	defer server.Close()
+	tooLong, tooLongCalls := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	defer tooLong.Close()
+
+	//do test
+	resp, err := newRetryingClient().Get(context.Background(), server.URL)
+	tooLongResp, tooLongErr := newRetryingClient().Get(context.Background(), tooLong.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.Status)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Nil(t, tooLongErr)
+	assert.Equal(t, http.StatusTooManyRequests, tooLongResp.Status)
+	assert.Equal(t, int32(1), tooLongCalls.Load())
+}
+
+func TestGetRetriesNetworkErrorsWithinBudget(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	client := newRetryingClient()
+	client.Retry.BaseDelay = 40 * time.Millisecond
+	client.Retry.MaxRetries = 10
+	client.Retry.Budget = 100 * time.Millisecond
+
+	//do test
+	start := time.Now()
+	_, err := client.Get(context.Background(), url)
+
+	//assertions
+	assert.ErrorContains(t, err, "failed to fetch URL")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestGetDoesNotWaitPastContextDeadline(t *testing.T) {
+	//prepare data
+	server, calls := flakyServer(10, http.StatusBadGateway, nil)
+	defer server.Close()
+	client := newRetryingClient()
+	client.Retry.BaseDelay = time.Second
+	client.Retry.MaxDelay = time.Second
+	client.Retry.Budget = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	//do test
+	start := time.Now()
+	resp, err := client.Get(ctx, server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.Status)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestGetRevalidatesWithETag(t *testing.T) {
+	//prepare data
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<html>berita</html>"))
+	}))
+	defer server.Close()
+	client := newRetryingClient()
+	client.Pages = utils.NewPageStore()
+
+	//do test
+	first, err := client.Get(context.Background(), server.URL)
+	assert.Nil(t, err)
+	second, err := client.Get(context.Background(), server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.False(t, first.NotModified)
+	assert.True(t, second.NotModified)
+	assert.Equal(t, http.StatusOK, second.Status)
+	assert.Equal(t, "<html>berita</html>", second.Body)
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestGetRevalidatesWithLastModified(t *testing.T) {
+	//prepare data
+	lastModified := "Wed, 01 May 2024 10:00:00 GMT"
+	var sawIfModifiedSince atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			sawIfModifiedSince.Store(true)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("<html>indeks</html>"))
+	}))
+	defer server.Close()
+	client := newRetryingClient()
+	client.Pages = utils.NewPageStore()
+
+	//do test
+	client.Get(context.Background(), server.URL)
+	resp, err := client.Get(context.Background(), server.URL)
+
+	//assertions
+	assert.Nil(t, err)
+	assert.True(t, sawIfModifiedSince.Load())
+	assert.Equal(t, "<html>indeks</html>", resp.Body)
+}
+
+func TestGetRejectsOversizedBodies(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/chunked" {
+			// no Content-Length, so the limit is enforced while reading
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer server.Close()
+	client := newRetryingClient()
+	client.MaxBodyBytes = 1024
+
+	//do test
+	_, err := client.Get(context.Background(), server.URL)
+	_, chunkedErr := client.Get(context.Background(), server.URL+"/chunked")
+
+	//assertions
+	assert.ErrorIs(t, err, utils.ErrTooLarge)
+	assert.ErrorIs(t, chunkedErr, utils.ErrTooLarge)
+}
+
+func TestGetRejectsNonHTML(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer server.Close()
+
+	//do test
+	_, err := newRetryingClient().Get(context.Background(), server.URL)
+
+	//assertions
+	assert.ErrorIs(t, err, utils.ErrNotHTML)
+}
+
+func TestGetDecodesCharset(t *testing.T) {
+	//prepare data
+	// "Berita terkini – café" in windows-1252, declared in the header and in a <meta> tag
+	body := "<html><body>Berita terkini \x96 caf\xe9</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/meta" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><meta charset="windows-1252"></head>` + body[6:]))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	//do test
+	resp, err := newRetryingClient().Get(context.Background(), server.URL)
+	metaResp, metaErr := newRetryingClient().Get(context.Background(), server.URL+"/meta")
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "<html><body>Berita terkini – café</body></html>", resp.Body)
+	assert.Nil(t, metaErr)
+	assert.Contains(t, metaResp.Body, "Berita terkini – café")
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/akhmadreiza/gober/models"
+)
+
+// LinkBuilder builds the Gober links handed to clients for upstream articles.
+// The zero value produces relative links, which is what CLIs, background jobs
+// and tests usually want.
+type LinkBuilder struct {
+	BaseURL string
+}
+
+// NewRequestLinkBuilder derives absolute links from the host and scheme of an
+// incoming request.
+func NewRequestLinkBuilder(r *http.Request) LinkBuilder {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return LinkBuilder{BaseURL: scheme + "://" + r.Host}
+}
+
+// Reader returns the web frontend's ad-free reader page for sourceURL.
+func (l LinkBuilder) Reader(source, sourceURL string) string {
+	return l.BaseURL + "/detail?source=" + source + "&detailUrl=" + url.QueryEscape(sourceURL)
+}
+
+// Article returns the Gober detail endpoint for detailURL on the given source.
+func (l LinkBuilder) Article(source, detailURL string) string {
+	return l.BaseURL + "/article?source=" + source + "&detailUrl=" + url.QueryEscape(detailURL)
+}
+
+// Resolve returns a copy of articles whose relative Gober links point at the
+// builder's base. Lists shared between requests, like the cached popular
+// lists, keep relative links and are resolved per request.
+func (l LinkBuilder) Resolve(articles []models.Article) []models.Article {
+	resolved := make([]models.Article, len(articles))
+	for i, article := range articles {
+		if strings.HasPrefix(article.URL, "/") {
+			article.URL = l.BaseURL + article.URL
+		}
+		resolved[i] = article
+	}
+	return resolved
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenDestination is returned when a fetch, or one of its redirects,
+// would leave the allowed sites or reach a non-public address.
+var ErrForbiddenDestination = errors.New("destination not allowed")
+
+// OutboundPolicy hardens an http.Client against SSRF: every request and
+// redirect hop is checked against AllowURL, and connections are refused to private, loopback
+// and link-local addresses whatever name resolved to them.
+type OutboundPolicy struct {
+	// AllowURL reports whether Gober may fetch u; nil allows any public URL.
+	AllowURL func(u *url.URL) bool
+	// AllowPrivate lifts the address check, e.g. for tests against a local
+	// server.
+	AllowPrivate bool
+	MaxRedirects int
+}
+
+// Apply installs the policy's redirect check and dialer on client. The
+// transport doesn't use a proxy, since the address check must see the
+// real destination.
+func (p OutboundPolicy) Apply(client *http.Client) {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	client.Transport = checkedTransport{policy: p, next: transport}
+	client.CheckRedirect = p.checkRedirect
+}
+
+// checkedTransport checks each request's URL before sending it, which
+// covers the first request of a fetch as well as every redirect.
+type checkedTransport struct {
+	policy OutboundPolicy
+	next   http.RoundTripper
+}
+
+func (t checkedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.checkURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+func (p OutboundPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	maxRedirects := p.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := p.checkURL(req.URL); err != nil {
+		return fmt.Errorf("redirect from %s: %w", via[len(via)-1].URL.Redacted(), err)
+	}
+	return nil
+}
+
+func (p OutboundPolicy) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, u.Redacted())
+	}
+	if p.AllowURL != nil && !p.AllowURL(u) {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, u.Redacted())
+	}
+	return nil
+}
+
+// control runs after DNS resolution, just before connecting, so it sees
+// the address actually dialled.
+func (p OutboundPolicy) control(network, address string, _ syscall.RawConn) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, address)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s is not a public address", ErrForbiddenDestination, addrPort.Addr())
+	}
+	return nil
+}
+
+// sharedAddressSpace is carrier-grade NAT (RFC 6598), private in practice.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,87 @@
+package utils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPolicyClient(policy utils.OutboundPolicy) *utils.RealHTTPClient {
+	client := newRetryingClient()
+	policy.Apply(client.Client)
+	return client
+}
+
+func TestOutboundPolicyRefusesPrivateAddresses(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>internal</html>"))
+	}))
+	defer server.Close()
+	client := newPolicyClient(utils.OutboundPolicy{})
+
+	//do test
+	_, loopbackErr := client.Get(context.Background(), server.URL)
+	_, metadataErr := client.Get(context.Background(), "http://169.254.169.254/latest/meta-data/")
+	_, privateErr := client.Get(context.Background(), "http://10.0.0.1/")
+
+	//assertions
+	assert.ErrorIs(t, loopbackErr, utils.ErrForbiddenDestination)
+	assert.ErrorIs(t, metadataErr, utils.ErrForbiddenDestination)
+	assert.ErrorIs(t, privateErr, utils.ErrForbiddenDestination)
+}
+
+func TestOutboundPolicyChecksRequestsAndRedirects(t *testing.T) {
+	//prepare data
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>secret</html>"))
+	}))
+	defer internal.Close()
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/escape":
+			http.Redirect(w, r, internal.URL+"/admin", http.StatusFound)
+		case "/moved":
+			http.Redirect(w, r, "/berita/1", http.StatusMovedPermanently)
+		default:
+			w.Write([]byte("<html>berita</html>"))
+		}
+	}))
+	defer allowed.Close()
+	allowedHost := allowed.Listener.Addr().String()
+	client := newPolicyClient(utils.OutboundPolicy{
+		AllowPrivate: true,
+		AllowURL:     func(u *url.URL) bool { return u.Host == allowedHost },
+	})
+
+	//do test
+	_, directErr := client.Get(context.Background(), internal.URL+"/admin")
+	_, escapeErr := client.Get(context.Background(), allowed.URL+"/escape")
+	resp, movedErr := client.Get(context.Background(), allowed.URL+"/moved")
+
+	//assertions
+	assert.ErrorIs(t, directErr, utils.ErrForbiddenDestination)
+	assert.ErrorIs(t, escapeErr, utils.ErrForbiddenDestination)
+	assert.Nil(t, movedErr)
+	assert.Equal(t, "<html>berita</html>", resp.Body)
+}
+
+func TestOutboundPolicyLimitsRedirects(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/again", http.StatusFound)
+	}))
+	defer server.Close()
+	client := newPolicyClient(utils.OutboundPolicy{AllowPrivate: true, MaxRedirects: 3})
+
+	//do test
+	_, err := client.Get(context.Background(), server.URL)
+
+	//assertions
+	assert.ErrorContains(t, err, "stopped after 3 redirects")
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Class and id hints, in the spirit of Mozilla's Readability, with the
+// Indonesian names the supported sites use for related-article boxes.
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|story|detail|read|isi`)
+	negativeHint = regexp.MustCompile(`(?i)comment|komentar|contact|foot|masthead|meta|outbrain|promo|related|terkait|baca|rekomendasi|populer|share|sidebar|sponsor|shopping|tags|tool|widget|nav|menu|banner|ads|social`)
+)
+
+// skippedTags never hold article text.
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "form": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "button": true,
+}
+
+// paragraphTags carry the text that is scored.
+var paragraphTags = map[string]bool{"p": true, "pre": true, "td": true, "blockquote": true}
+
+// blockTags make a div a container rather than a paragraph of its own.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "pre": true, "table": true, "blockquote": true,
+	"ul": true, "ol": true, "article": true, "section": true, "dl": true,
+}
+
+// minParagraphLen is the text length below which a paragraph is ignored as
+// a caption, byline or button label.
+const minParagraphLen = 25
+
+// ExtractContent finds the element holding a page's main text without
+// site-specific selectors: paragraphs add points to their parent and
+// grandparent for their length and commas, containers gain or lose points
+// for their class and id, and each candidate's score is scaled down by the
+// share of its text that is links. It returns an empty selection when no
+// paragraph qualifies.
+func ExtractContent(doc *goquery.Document) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+
+	addScore := func(n *html.Node, points float64) {
+		if n == nil || n.Type != html.ElementNode || n.Data == "body" || n.Data == "html" {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = baseScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += points
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if skippedTags[n.Data] {
+				return
+			}
+			if paragraphTags[n.Data] || (n.Data == "div" && !hasBlockChild(n)) {
+				text := strings.TrimSpace(nodeText(n))
+				if len(text) >= minParagraphLen {
+					points := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+					addScore(n.Parent, points)
+					if n.Parent != nil {
+						addScore(n.Parent.Parent, points/2)
+					}
+				}
+				if n.Data != "div" {
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, root := range doc.Nodes {
+		walk(root)
+	}
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil || bestScore <= 0 {
+		return doc.FindNodes()
+	}
+	return doc.FindNodes(best)
+}
+
+// MainContent returns content, or what ExtractContent finds in doc when
+// content has no text, e.g. because the site changed its layout.
+func MainContent(doc *goquery.Document, content *goquery.Selection, pageURL string) *goquery.Selection {
+	if strings.TrimSpace(content.Text()) != "" {
+		return content
+	}
+	log.Printf("[Readability] no content at the site selectors for %s, extracting it generically", pageURL)
+	return ExtractContent(doc)
+}
+
+// baseScore is a candidate's starting score from its tag, class and id.
+func baseScore(n *html.Node) float64 {
+	var score float64
+	switch n.Data {
+	case "article", "main":
+		score = 10
+	case "div", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "ul", "ol", "dl", "li", "th":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		score = -5
+	}
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeHint.MatchString(attr.Val) {
+			score -= 25
+		}
+		if positiveHint.MatchString(attr.Val) {
+			score += 25
+		}
+	}
+	return score
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockTags[c.Data] {
+			return true
+		}
+	}
+	return false
+}
+
+// linkDensity is the share of n's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(strings.TrimSpace(nodeText(n)))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			linked += len(strings.TrimSpace(nodeText(c)))
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+	return min(float64(linked)/float64(total), 1)
+}
+
+// nodeText is n's text content, leaving out scripts and styles.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		switch c.Type {
+		case html.TextNode:
+			b.WriteString(c.Data)
+		case html.ElementNode:
+			if c.Data == "script" || c.Data == "style" || c.Data == "noscript" {
+				return
+			}
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const readabilityPage = `<html><head>
+	<title>Judul Berita - Situs</title>
+	<meta property="og:title" content="Judul Berita">
+	<meta property="og:image" content="https://example.com/foto.jpg">
+	<meta name="author" content="Penulis">
+	<meta property="article:published_time" content="2024-05-01T10:00:00+07:00">
+</head><body>
+	<nav><a href="/">Beranda</a> <a href="/news">News</a></nav>
+	<div class="layout-2024">
+		<div class="story-wrap">
+			<p>Paragraf pertama berita, dengan cukup banyak teks, koma, dan detail peristiwa.</p>
+			<p>Paragraf kedua menjelaskan latar belakang, tanggapan pejabat, serta dampaknya.</p>
+			<p>Paragraf ketiga berisi kutipan narasumber, angka, dan rencana selanjutnya.</p>
+		</div>
+		<div class="sidebar">
+			<p><a href="/a">Berita populer pertama yang panjang judulnya sekali</a></p>
+			<p><a href="/b">Berita populer kedua yang juga panjang judulnya</a></p>
+		</div>
+	</div>
+	<footer><p>Hak cipta dilindungi undang-undang, seluruh isi situs ini.</p></footer>
+</body></html>`
+
+func TestExtractContentPicksArticleBody(t *testing.T) {
+	//prepare data
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(readabilityPage))
+
+	//do test
+	content := utils.ExtractContent(doc)
+
+	//assertions
+	assert.True(t, content.HasClass("story-wrap"))
+	assert.Contains(t, content.Text(), "Paragraf ketiga")
+	assert.NotContains(t, content.Text(), "Berita populer")
+}
+
+func TestExtractContentNoParagraphs(t *testing.T) {
+	//prepare data
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<html><body><div><a href="/">Home</a></div></body></html>`))
+
+	//do test
+	content := utils.ExtractContent(doc)
+
+	//assertions
+	assert.Equal(t, 0, content.Length())
+}
+
+func TestMainContentKeepsSiteSelection(t *testing.T) {
+	//prepare data
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(readabilityPage))
+
+	//do test
+	kept := utils.MainContent(doc, doc.Find("div.sidebar"), "https://example.com/berita")
+	extracted := utils.MainContent(doc, doc.Find("div.detail__body-text"), "https://example.com/berita")
+
+	//assertions
+	assert.True(t, kept.HasClass("sidebar"))
+	assert.True(t, extracted.HasClass("story-wrap"))
+}
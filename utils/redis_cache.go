@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+)
+
+// RedisConfig locates a Redis (or Redis protocol compatible) server.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Prefix namespaces every key, so replicas of different deployments can
+	// share a server.
+	Prefix string
+	// PoolSize caps idle connections kept for reuse.
+	PoolSize int
+	// Timeout bounds dialing and each command round trip.
+	Timeout time.Duration
+}
+
+// ParseRedisURL parses redis://[:password@]host[:port][/db].
+func ParseRedisURL(raw string) (RedisConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return RedisConfig{}, fmt.Errorf("invalid redis url %q", raw)
+	}
+	cfg := RedisConfig{Addr: u.Host, Prefix: "gober:", PoolSize: 8, Timeout: 2 * time.Second}
+	if u.Port() == "" {
+		cfg.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		cfg.Password = password
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if cfg.DB, err = strconv.Atoi(db); err != nil {
+			return RedisConfig{}, fmt.Errorf("invalid redis db %q", db)
+		}
+	}
+	return cfg, nil
+}
+
+// RedisCache is a CacheOps shared by every Gober replica pointing at the same
+// server. Values are stored as JSON tagged with their registered type name
+// (see RegisterCacheType), and kept by Redis for StaleFor past their ttl so
+// Fetch can serve them stale while refreshing.
+type RedisCache struct {
+	cfg      RedisConfig
+	idle     chan *respConn
+	flights  flightGroup
+	StaleFor time.Duration
+
+	hits, staleHits, misses atomic.Int64
+}
+
+func NewRedisCache(cfg RedisConfig) *RedisCache {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 8
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return &RedisCache{cfg: cfg, idle: make(chan *respConn, cfg.PoolSize), StaleFor: DefaultStaleFor}
+}
+
+// Ping checks the server is reachable, e.g. at startup.
+func (c *RedisCache) Ping() error {
+	_, err := c.command("PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *RedisCache) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisCache) Get(key string) (interface{}, bool) {
+	value, state := c.lookup(key)
+	if state != entryFresh {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return value, true
+}
+
+func (c *RedisCache) Set(key string, value interface{}, ttl time.Duration) {
+	entry, err := encodeCacheEntry(value, time.Now().Add(ttl))
+	if err != nil {
+		log.Printf("[Cache] can't store %s in redis: %v", key, err)
+		return
+	}
+	// Redis drops the key once it can't even be served stale.
+	keep := ttl + c.StaleFor
+	if keep < time.Millisecond {
+		return
+	}
+	if _, err := c.command("SET", c.cfg.Prefix+key, string(entry), "PX", strconv.FormatInt(keep.Milliseconds(), 10)); err != nil {
+		log.Printf("[Cache] redis SET %s failed: %v", key, err)
+	}
+}
+
+func (c *RedisCache) Fetch(ctx context.Context, key string, load Loader) (interface{}, error) {
+	lookup := func() (interface{}, entryState) {
+		value, state := c.lookup(key)
+		switch state {
+		case entryFresh:
+			c.hits.Add(1)
+		case entryStale:
+			c.staleHits.Add(1)
+		default:
+			c.misses.Add(1)
+		}
+		return value, state
+	}
+	return readThrough(ctx, &c.flights, key, lookup, c.Set, load)
+}
+
+// Stats returns the hit counters. Entries and sizes live on the server.
+func (c *RedisCache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), StaleHits: c.staleHits.Load(), Misses: c.misses.Load()}
+}
+
+// lookup reads and decodes key. Errors are logged and reported as a miss,
+// so a Redis outage degrades to scraping rather than failing requests.
+func (c *RedisCache) lookup(key string) (interface{}, entryState) {
+	reply, err := c.command("GET", c.cfg.Prefix+key)
+	if err != nil {
+		log.Printf("[Cache] redis GET %s failed: %v", key, err)
+		return nil, entryMissing
+	}
+	raw, ok := reply.(string)
+	if !ok {
+		return nil, entryMissing
+	}
+	value, expiresAt, err := decodeCacheEntry([]byte(raw))
+	if err != nil {
+		log.Printf("[Cache] can't decode %s from redis: %v", key, err)
+		return nil, entryMissing
+	}
+	if time.Now().Before(expiresAt) {
+		return value, entryFresh
+	}
+	return value, entryStale
+}
+
+// command runs one command on a pooled connection.
+func (c *RedisCache) command(args ...string) (interface{}, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(time.Now().Add(c.cfg.Timeout), args...)
+	if err != nil {
+		// The connection may be mid-reply; don't reuse it.
+		conn.conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	if redisErr, ok := reply.(redisError); ok {
+		return nil, redisErr
+	}
+	return reply, nil
+}
+
+func (c *RedisCache) conn() (*respConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", c.cfg.Addr, c.cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := newRESPConn(netConn)
+	deadline := time.Now().Add(c.cfg.Timeout)
+	if c.cfg.Password != "" {
+		if err := expectOK(conn.do(deadline, "AUTH", c.cfg.Password)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.cfg.DB != 0 {
+		if err := expectOK(conn.do(deadline, "SELECT", strconv.Itoa(c.cfg.DB))); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *RedisCache) release(conn *respConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func expectOK(reply interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	if redisErr, ok := reply.(redisError); ok {
+		return redisErr
+	}
+	return nil
+}
+
+// cacheTypes maps the type names stored alongside serialized values to Go
+// types, so RedisCache hands back the same types the in-memory cache would.
+var cacheTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{byName: map[string]reflect.Type{}, byType: map[reflect.Type]string{}}
+
+// RegisterCacheType makes values of type T storable in RedisCache under name.
+func RegisterCacheType[T any](name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	cacheTypes.Lock()
+	defer cacheTypes.Unlock()
+	cacheTypes.byName[name] = t
+	cacheTypes.byType[t] = name
+}
+
+func init() {
+	RegisterCacheType[models.Article]("article")
+	RegisterCacheType[[]models.Article]("articles")
+	RegisterCacheType[string]("string")
+}
+
+type cacheEntry struct {
+	Type      string          `json:"type"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func encodeCacheEntry(value interface{}, expiresAt time.Time) ([]byte, error) {
+	cacheTypes.RLock()
+	name, ok := cacheTypes.byType[reflect.TypeOf(value)]
+	cacheTypes.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("type %T is not registered", value)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(cacheEntry{Type: name, ExpiresAt: expiresAt, Data: data})
+}
+
+func decodeCacheEntry(raw []byte) (interface{}, time.Time, error) {
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, time.Time{}, err
+	}
+	cacheTypes.RLock()
+	t, ok := cacheTypes.byName[entry.Type]
+	cacheTypes.RUnlock()
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("unknown cached type %q", entry.Type)
+	}
+	value := reflect.New(t)
+	if err := json.Unmarshal(entry.Data, value.Interface()); err != nil {
+		return nil, time.Time{}, err
+	}
+	return value.Elem().Interface(), entry.ExpiresAt, nil
+}
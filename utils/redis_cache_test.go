@@ -0,0 +1,144 @@
+package utils_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisCache(t *testing.T, server *utils.FakeRedisServer) *utils.RedisCache {
+	cfg, err := utils.ParseRedisURL(server.URL())
+	assert.Nil(t, err)
+	cache := utils.NewRedisCache(cfg)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestParseRedisURL(t *testing.T) {
+	//do test
+	cfg, err := utils.ParseRedisURL("redis://:secret@cache.internal/2")
+	_, errScheme := utils.ParseRedisURL("http://cache.internal")
+	_, errDB := utils.ParseRedisURL("redis://cache.internal/one")
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "cache.internal:6379", cfg.Addr)
+	assert.Equal(t, "secret", cfg.Password)
+	assert.Equal(t, 2, cfg.DB)
+	assert.Equal(t, "gober:", cfg.Prefix)
+	assert.EqualError(t, errScheme, `invalid redis url "http://cache.internal"`)
+	assert.EqualError(t, errDB, `invalid redis db "one"`)
+}
+
+func TestRedisCacheRoundTripsArticles(t *testing.T) {
+	//prepare data
+	server, err := utils.NewFakeRedisServer("secret")
+	assert.Nil(t, err)
+	defer server.Close()
+	cache := newTestRedisCache(t, server)
+	publishedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	articles := []models.Article{
+		{Title: "Satu", URL: "https://news.detik.com/berita/d-1/satu", Source: "detik", PublishedAt: &publishedAt},
+		{Title: "Dua", URL: "https://news.detik.com/berita/d-2/dua", Source: "detik"},
+	}
+	detail := models.Article{Title: "Satu", Content: "<p>isi</p>", Source: "detik"}
+
+	//do test
+	cache.Set("detik:popular", articles, time.Minute)
+	cache.Set("detik:https://news.detik.com/berita/d-1/satu", detail, time.Minute)
+	gotList, foundList := cache.Get("detik:popular")
+	gotDetail, foundDetail := cache.Get("detik:https://news.detik.com/berita/d-1/satu")
+
+	//assertions
+	assert.True(t, foundList)
+	assert.Equal(t, articles, gotList)
+	assert.True(t, foundDetail)
+	assert.Equal(t, detail, gotDetail)
+}
+
+func TestRedisCacheSharedBetweenReplicas(t *testing.T) {
+	//prepare data
+	server, err := utils.NewFakeRedisServer("")
+	assert.Nil(t, err)
+	defer server.Close()
+	first := newTestRedisCache(t, server)
+	second := newTestRedisCache(t, server)
+	var calls atomic.Int32
+
+	//do test
+	value, err := utils.FetchAs(context.Background(), first, "kompas:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		calls.Add(1)
+		return []models.Article{{Title: "Satu"}}, time.Minute, nil
+	})
+	assert.Nil(t, err)
+	shared, err := utils.FetchAs(context.Background(), second, "kompas:popular", func(ctx context.Context) ([]models.Article, time.Duration, error) {
+		calls.Add(1)
+		return nil, time.Minute, nil
+	})
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, value, shared)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(1), second.Stats().Hits)
+}
+
+func TestRedisCacheServesStaleWhileRefreshing(t *testing.T) {
+	//prepare data
+	server, err := utils.NewFakeRedisServer("")
+	assert.Nil(t, err)
+	defer server.Close()
+	cache := newTestRedisCache(t, server)
+	cache.Set("detik:popular", "old", -time.Second)
+	var calls atomic.Int32
+
+	//do test
+	value, err := cache.Fetch(context.Background(), "detik:popular", countingLoader(&calls, nil, time.Minute))
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "old", value)
+	assert.Eventually(t, func() bool {
+		value, found := cache.Get("detik:popular")
+		return found && value == "v1"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), cache.Stats().StaleHits)
+}
+
+func TestRedisCacheSkipsUnregisteredTypes(t *testing.T) {
+	//prepare data
+	server, err := utils.NewFakeRedisServer("")
+	assert.Nil(t, err)
+	defer server.Close()
+	cache := newTestRedisCache(t, server)
+
+	//do test
+	cache.Set("answer", 42, time.Minute)
+
+	//assertions
+	_, found := cache.Get("answer")
+	assert.False(t, found)
+}
+
+func TestRedisCacheDegradesWhenServerIsDown(t *testing.T) {
+	//prepare data
+	server, err := utils.NewFakeRedisServer("")
+	assert.Nil(t, err)
+	cache := newTestRedisCache(t, server)
+	assert.Nil(t, cache.Ping())
+	server.Close()
+	var calls atomic.Int32
+
+	//do test
+	value, err := cache.Fetch(context.Background(), "detik:popular", countingLoader(&calls, nil, time.Minute))
+
+	//assertions
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", value)
+	assert.NotNil(t, cache.Ping())
+}
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeRedisServer is an in-process server speaking enough of the Redis
+// protocol for RedisCache's tests: PING, AUTH, SELECT, GET, SET (with PX and
+// NX), DEL and FLUSHALL.
+type FakeRedisServer struct {
+	password string
+	listener net.Listener
+	mu       sync.Mutex
+	dbs      map[int]map[string]fakeRedisEntry
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+type fakeRedisEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewFakeRedisServer starts a server on a random local port. A non-empty
+// password must be sent with AUTH before other commands.
+func NewFakeRedisServer(password string) (*FakeRedisServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &FakeRedisServer{password: password, listener: listener, dbs: map[int]map[string]fakeRedisEntry{}, conns: map[net.Conn]struct{}{}}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *FakeRedisServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL is a redis:// URL for the server, for ParseRedisURL.
+func (s *FakeRedisServer) URL() string {
+	if s.password != "" {
+		return "redis://:" + s.password + "@" + s.Addr()
+	}
+	return "redis://" + s.Addr()
+}
+
+// Close stops the server and drops its client connections.
+func (s *FakeRedisServer) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *FakeRedisServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *FakeRedisServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authed := s.password == ""
+	db := 0
+	for {
+		request, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		items, _ := request.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if len(args) == 0 {
+			w.WriteString("-ERR empty command\r\n")
+			w.Flush()
+			continue
+		}
+
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "AUTH":
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				w.WriteString("+OK\r\n")
+			} else {
+				w.WriteString("-WRONGPASS invalid password\r\n")
+			}
+		case !authed:
+			w.WriteString("-NOAUTH Authentication required.\r\n")
+		case name == "SELECT":
+			if len(args) != 2 {
+				w.WriteString("-ERR wrong number of arguments\r\n")
+			} else if n, err := strconv.Atoi(args[1]); err != nil {
+				w.WriteString("-ERR invalid DB index\r\n")
+			} else {
+				db = n
+				w.WriteString("+OK\r\n")
+			}
+		default:
+			s.exec(w, db, name, args[1:])
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *FakeRedisServer) exec(w *bufio.Writer, db int, name string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := s.dbs[db]
+	if keys == nil {
+		keys = map[string]fakeRedisEntry{}
+		s.dbs[db] = keys
+	}
+	now := time.Now()
+	live := func(key string) (fakeRedisEntry, bool) {
+		entry, ok := keys[key]
+		if ok && !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(keys, key)
+			return entry, false
+		}
+		return entry, ok
+	}
+
+	switch name {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "GET":
+		if len(args) != 1 {
+			w.WriteString("-ERR wrong number of arguments\r\n")
+			return
+		}
+		entry, ok := live(args[0])
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		w.WriteString("$" + strconv.Itoa(len(entry.value)) + "\r\n" + entry.value + "\r\n")
+	case "SET":
+		if len(args) < 2 {
+			w.WriteString("-ERR wrong number of arguments\r\n")
+			return
+		}
+		entry := fakeRedisEntry{value: args[1]}
+		nx := false
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				if i+1 == len(args) {
+					w.WriteString("-ERR syntax error\r\n")
+					return
+				}
+				ms, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil || ms <= 0 {
+					w.WriteString("-ERR invalid expire time in 'set' command\r\n")
+					return
+				}
+				entry.expiresAt = now.Add(time.Duration(ms) * time.Millisecond)
+				i++
+			default:
+				w.WriteString("-ERR syntax error\r\n")
+				return
+			}
+		}
+		if _, exists := live(args[0]); nx && exists {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		keys[args[0]] = entry
+		w.WriteString("+OK\r\n")
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := live(key); ok {
+				delete(keys, key)
+				deleted++
+			}
+		}
+		w.WriteString(":" + strconv.Itoa(deleted) + "\r\n")
+	case "FLUSHALL":
+		s.dbs = map[int]map[string]fakeRedisEntry{}
+		w.WriteString("+OK\r\n")
+	default:
+		w.WriteString("-ERR unknown command '" + name + "'\r\n")
+	}
+}
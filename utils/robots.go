@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned by RealHTTPClient.Get when the site's robots.txt
+// forbids Gober from fetching the URL.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsAgent is the product token Gober matches robots.txt groups against.
+const robotsAgent = "gober"
+
+// RobotsPolicy fetches, caches and applies each host's robots.txt.
+type RobotsPolicy struct {
+	Client *http.Client
+	// TTL is how long a host's robots.txt is trusted before refetching.
+	TTL time.Duration
+	// Ignore lists hosts (subdomains included) whose robots.txt the operator
+	// chose to override; "*" ignores every host.
+	Ignore []string
+
+	mu      sync.Mutex
+	hosts   map[string]robotsEntry
+	flights flightGroup
+}
+
+type robotsEntry struct {
+	rules     robotsRules
+	expiresAt time.Time
+}
+
+func NewRobotsPolicy(client *http.Client, ignore []string) *RobotsPolicy {
+	return &RobotsPolicy{Client: client, TTL: time.Hour, Ignore: ignore, hosts: map[string]robotsEntry{}}
+}
+
+// Check reports whether u may be fetched, returning an error wrapping
+// ErrDisallowed if not, along with the host's Crawl-delay.
+func (p *RobotsPolicy) Check(ctx context.Context, u *url.URL) (crawlDelay time.Duration, err error) {
+	host := strings.ToLower(u.Host)
+	if u.Path == "/robots.txt" || p.ignored(u.Hostname()) {
+		return 0, nil
+	}
+
+	rules, err := p.rules(ctx, u.Scheme, host)
+	if err != nil {
+		return 0, err
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rules.allows(path) {
+		return 0, fmt.Errorf("%w: %s", ErrDisallowed, u.Redacted())
+	}
+	return rules.crawlDelay, nil
+}
+
+func (p *RobotsPolicy) ignored(host string) bool {
+	host = strings.ToLower(host)
+	for _, ignored := range p.Ignore {
+		ignored = strings.ToLower(strings.TrimSpace(ignored))
+		if ignored == "*" || host == ignored || strings.HasSuffix(host, "."+ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RobotsPolicy) rules(ctx context.Context, scheme, host string) (robotsRules, error) {
+	key := scheme + "://" + host
+	p.mu.Lock()
+	entry, ok := p.hosts[key]
+	p.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.rules, nil
+	}
+
+	// fetch has its own timeout, and the rules are worth keeping anyway
+	f := p.flights.start(ctx, key, true, func(context.Context) (interface{}, error) {
+		rules, ttl := p.fetch(key + "/robots.txt")
+		p.mu.Lock()
+		p.hosts[key] = robotsEntry{rules: rules, expiresAt: time.Now().Add(ttl)}
+		p.mu.Unlock()
+		return rules, nil
+	})
+	rules, err := p.flights.wait(ctx, f)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	return rules.(robotsRules), nil
+}
+
+// disallowAll is what an unreachable robots.txt means (RFC 9309 2.3.1.4).
+var disallowAll = robotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+
+// fetch downloads and parses robots.txt. A missing file (4xx) allows
+// everything. One we can't get at all, for a network error or a 5xx,
+// disallows everything, but that is only trusted for a minute.
+func (p *RobotsPolicy) fetch(robotsURL string) (robotsRules, time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return disallowAll, time.Minute
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		log.Printf("[Robots] can't fetch %s: %v", robotsURL, err)
+		return disallowAll, time.Minute
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		log.Printf("[Robots] %s answered %d, disallowing the host for now", robotsURL, resp.StatusCode)
+		return disallowAll, time.Minute
+	}
+	if resp.StatusCode >= 400 {
+		return robotsRules{}, p.TTL
+	}
+	// robots.txt is capped at 500 KiB by RFC 9309.
+	return parseRobots(io.LimitReader(resp.Body, 500<<10), robotsAgent), p.TTL
+}
+
+// robotsRules is the robots.txt group that applies to Gober.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allows applies the most specific (longest) matching rule; Allow wins ties.
+func (r robotsRules) allows(path string) bool {
+	best, allowed := -1, true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > best || (len(rule.pattern) == best && rule.allow) {
+			best, allowed = len(rule.pattern), rule.allow
+		}
+	}
+	return allowed
+}
+
+// parseRobots keeps the groups naming agent, or the "*" groups when none do.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	type group struct {
+		agents []string
+		robotsRules
+	}
+	var groups []*group
+	var current *group
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &group{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			token, _, _ := strings.Cut(value, "/")
+			current.agents = append(current.agents, strings.ToLower(strings.TrimSpace(token)))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var named, wildcard robotsRules
+	matchedName := false
+	for _, g := range groups {
+		var target *robotsRules
+		for _, a := range g.agents {
+			if a == agent {
+				target, matchedName = &named, true
+				break
+			}
+			if a == "*" {
+				target = &wildcard
+			}
+		}
+		if target == nil {
+			continue
+		}
+		target.rules = append(target.rules, g.rules...)
+		if g.crawlDelay > target.crawlDelay {
+			target.crawlDelay = g.crawlDelay
+		}
+	}
+	if matchedName {
+		return named
+	}
+	return wildcard
+}
+
+// robotsMatch matches a robots.txt path pattern, where * is any run of
+// characters and a trailing $ anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
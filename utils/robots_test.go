@@ -0,0 +1,136 @@
+package utils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `# robots for a news site
+User-agent: *
+Disallow: /search
+Crawl-delay: 1
+
+User-agent: Gober/1.0
+User-agent: OtherBot
+Disallow: /private
+Disallow: /*.pdf$
+Allow: /private/public
+Crawl-delay: 0.5
+`
+
+func robotsServer(robots string, status int) (*httptest.Server, *atomic.Int32) {
+	var robotsCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsCalls.Add(1)
+			w.WriteHeader(status)
+			w.Write([]byte(robots))
+			return
+		}
+		w.Write([]byte("<html>ok</html>"))
+	}))
+	return server, &robotsCalls
+}
+
+func TestRobotsPolicyCheck(t *testing.T) {
+	//prepare data
+	server, robotsCalls := robotsServer(testRobots, http.StatusOK)
+	defer server.Close()
+	policy := utils.NewRobotsPolicy(server.Client(), nil)
+	check := func(path string) (time.Duration, error) {
+		u, _ := url.Parse(server.URL + path)
+		return policy.Check(context.Background(), u)
+	}
+
+	//do test
+	delay, errAllowed := check("/search?q=banjir")
+	_, errPrivate := check("/private/notes")
+	_, errPublic := check("/private/public/page")
+	_, errPDF := check("/files/report.pdf")
+	_, errPDFQuery := check("/files/report.pdf?download=1")
+
+	//assertions
+	assert.Nil(t, errAllowed)
+	assert.Equal(t, 500*time.Millisecond, delay)
+	assert.ErrorIs(t, errPrivate, utils.ErrDisallowed)
+	assert.Nil(t, errPublic)
+	assert.ErrorIs(t, errPDF, utils.ErrDisallowed)
+	assert.Nil(t, errPDFQuery)
+	assert.Equal(t, int32(1), robotsCalls.Load())
+}
+
+func TestRobotsPolicyWildcardGroup(t *testing.T) {
+	//prepare data
+	server, _ := robotsServer("User-agent: *\nDisallow: /\nAllow: /$\n", http.StatusOK)
+	defer server.Close()
+	policy := utils.NewRobotsPolicy(server.Client(), nil)
+	home, _ := url.Parse(server.URL + "/")
+	article, _ := url.Parse(server.URL + "/berita/1")
+
+	//do test
+	_, errHome := policy.Check(context.Background(), home)
+	_, errArticle := policy.Check(context.Background(), article)
+
+	//assertions
+	assert.Nil(t, errHome)
+	assert.ErrorIs(t, errArticle, utils.ErrDisallowed)
+}
+
+func TestRobotsPolicyMissingFileAllowsAll(t *testing.T) {
+	//prepare data
+	server, _ := robotsServer("", http.StatusNotFound)
+	defer server.Close()
+	policy := utils.NewRobotsPolicy(server.Client(), nil)
+	u, _ := url.Parse(server.URL + "/private")
+
+	//do test
+	_, err := policy.Check(context.Background(), u)
+
+	//assertions
+	assert.Nil(t, err)
+}
+
+func TestRobotsPolicyUnreachableFileDisallowsAll(t *testing.T) {
+	//prepare data
+	server, _ := robotsServer("", http.StatusServiceUnavailable)
+	defer server.Close()
+	policy := utils.NewRobotsPolicy(server.Client(), nil)
+	u, _ := url.Parse(server.URL + "/berita/1")
+	down, _ := url.Parse("http://127.0.0.1:1/berita/1")
+
+	//do test
+	_, err := policy.Check(context.Background(), u)
+	_, downErr := policy.Check(context.Background(), down)
+
+	//assertions
+	assert.ErrorIs(t, err, utils.ErrDisallowed)
+	assert.ErrorIs(t, downErr, utils.ErrDisallowed)
+}
+
+func TestGetRefusesDisallowedURL(t *testing.T) {
+	//prepare data
+	server, _ := robotsServer(testRobots, http.StatusOK)
+	defer server.Close()
+	client := utils.NewHTTPClient()
+	client.Hosts = nil
+	ignoring := utils.NewHTTPClient()
+	ignoring.Hosts = nil
+	ignoring.Robots.Ignore = []string{"127.0.0.1"}
+
+	//do test
+	_, err := client.Get(context.Background(), server.URL+"/private/notes")
+	resp, ignoredErr := ignoring.Get(context.Background(), server.URL+"/private/notes")
+
+	//assertions
+	assert.ErrorIs(t, err, utils.ErrDisallowed)
+	assert.Nil(t, ignoredErr)
+	assert.Equal(t, http.StatusOK, resp.Status)
+}
@@ -1,71 +1,89 @@
 package utils
 
 import (
+	"context"
 	"log"
 	"strings"
 	"sync"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/akhmadreiza/gober/models"
-	"github.com/gin-gonic/gin"
 )
 
 type ScrapeUtils struct {
 	Client HTTPClient
+	// Parsed remembers what each list page parsed to, so a page the server
+	// reports as not modified isn't parsed again. nil disables it.
+	Parsed *Cache
 }
 
 func NewScrapeUtils(client HTTPClient) ScrapeUtils {
-	return ScrapeUtils{client}
+	parsed := NewCache()
+	parsed.StaleFor = 0
+	parsed.MaxBytes = DefaultPageMaxBytes / 2
+	return ScrapeUtils{Client: client, Parsed: parsed}
 }
 
-func (s ScrapeUtils) FetchListArticles(f func(doc *goquery.Document, c *gin.Context) []models.Article, urls []string, c *gin.Context) []models.Article {
-	// Create a channel to receive Articles
-	ch := make(chan []models.Article)
+// ListParser extracts articles from a fetched list page.
+type ListParser func(doc *goquery.Document, links LinkBuilder) []models.Article
+
+// FetchListArticles fetches urls concurrently and merges what f extracts from
+// each page, in the order of urls whatever order the pages arrive in, since
+// ranking uses an article's position in its source's list. Cancelling ctx
+// aborts the outstanding fetches.
+func (s ScrapeUtils) FetchListArticles(ctx context.Context, f ListParser, urls []string, links LinkBuilder) []models.Article {
+	pages := make([][]models.Article, len(urls))
 
 	// Use a WaitGroup to ensure all goroutines complete
 	var wg sync.WaitGroup
-
-	for _, url := range urls {
+	for i, url := range urls {
 		wg.Add(1)
-		go s.fetchListArticlesRoutine(url, ch, &wg, c, f)
+		go func(i int, url string) {
+			defer wg.Done()
+			pages[i] = s.fetchListPage(ctx, url, links, f)
+		}(i, url)
 	}
-
-	// Close the channel once all goroutines are done
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
+	wg.Wait()
 
 	var listArticles []models.Article
-	for result := range ch {
-		listArticles = append(listArticles, result...)
+	for _, page := range pages {
+		listArticles = append(listArticles, page...)
 	}
 	return listArticles
 }
 
-func (s ScrapeUtils) fetchListArticlesRoutine(url string, ch chan []models.Article, waitGroup *sync.WaitGroup, ginContext *gin.Context, f func(doc *goquery.Document, c *gin.Context) []models.Article) {
-	//call waitGroup.Done at the end of method
-	defer waitGroup.Done()
-
-	resp, err := s.Client.Get(url)
+// fetchListPage fetches one list page and parses it with f. Failures are
+// logged and yield no articles, so one broken page doesn't sink the list.
+func (s ScrapeUtils) fetchListPage(ctx context.Context, url string, links LinkBuilder, f ListParser) []models.Article {
+	resp, err := s.Client.Get(ctx, url)
 	if err != nil {
 		log.Printf("failed to fetch %s: %v", url, err)
-		ch <- []models.Article{}
-		return
+		return []models.Article{}
 	}
 
 	if resp.Status != 200 {
 		log.Printf("non-200 response %d fetching %s", resp.Status, url)
-		ch <- []models.Article{}
-		return
+		return []models.Article{}
+	}
+
+	// Parsed articles carry links, so the memo is per link base too.
+	parsedKey := url + " " + links.BaseURL
+	if resp.NotModified && s.Parsed != nil {
+		if articles, found := s.Parsed.Get(parsedKey); found {
+			// callers may tweak what they get, so hand out a copy
+			return append([]models.Article(nil), articles.([]models.Article)...)
+		}
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resp.Body))
 	if err != nil {
 		log.Printf("failed to parse HTML from %s: %v", url, err)
-		ch <- []models.Article{}
-		return
+		return []models.Article{}
 	}
 
-	ch <- f(doc, ginContext)
+	articles := f(doc, links)
+	if s.Parsed != nil {
+		s.Parsed.Set(parsedKey, articles, DefaultPageTTL)
+	}
+	return articles
 }
@@ -0,0 +1,108 @@
+package utils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/akhmadreiza/gober/models"
+	"github.com/akhmadreiza/gober/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchListArticlesCancelledContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	util := utils.NewScrapeUtils(utils.NewHTTPClient())
+	parse := func(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
+		return []models.Article{{Title: "should not be parsed"}}
+	}
+
+	start := time.Now()
+	result := util.FetchListArticles(ctx, parse, []string{server.URL, server.URL}, utils.LinkBuilder{})
+
+	assert.Equal(t, 0, len(result))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestFetchListArticlesKeepsURLOrder(t *testing.T) {
+	//prepare data
+	// later pages answer first
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		delay, _ := strconv.Atoi(page)
+		time.Sleep(time.Duration(4-delay) * 10 * time.Millisecond)
+		w.Write([]byte("<html><a>" + page + "</a></html>"))
+	}))
+	defer server.Close()
+	client := utils.NewHTTPClient()
+	client.Hosts = nil
+	client.Robots = nil
+	util := utils.NewScrapeUtils(client)
+	parse := func(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
+		return []models.Article{{Title: doc.Find("a").Text()}}
+	}
+	urls := []string{server.URL + "?page=1", server.URL + "?page=2", server.URL + "?page=3"}
+
+	//do test
+	first := util.FetchListArticles(context.Background(), parse, urls, utils.LinkBuilder{})
+	second := util.FetchListArticles(context.Background(), parse, urls, utils.LinkBuilder{})
+
+	//assertions
+	assert.Equal(t, []models.Article{{Title: "1"}, {Title: "2"}, {Title: "3"}}, first)
+	assert.Equal(t, first, second)
+}
+
+func TestLinkBuilderArticle(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://gober.local:8080/articles", nil)
+	links := utils.NewRequestLinkBuilder(req)
+
+	assert.Equal(t, "http://gober.local:8080/article?source=kompas&detailUrl=https%3A%2F%2Fkompas.com%2Fread", links.Article("kompas", "https://kompas.com/read"))
+	assert.Equal(t, "/article?source=detik&detailUrl=https%3A%2F%2Fdetik.com", utils.LinkBuilder{}.Article("detik", "https://detik.com"))
+}
+
+func TestFetchListArticlesReusesParsedResultWhenNotModified(t *testing.T) {
+	//prepare data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<html><a>Satu</a></html>"))
+	}))
+	defer server.Close()
+	client := utils.NewHTTPClient()
+	client.Hosts = nil
+	client.Robots = nil
+	util := utils.NewScrapeUtils(client)
+	parses := 0
+	parse := func(doc *goquery.Document, links utils.LinkBuilder) []models.Article {
+		parses++
+		return []models.Article{{Title: doc.Find("a").Text()}}
+	}
+
+	//do test
+	first := util.FetchListArticles(context.Background(), parse, []string{server.URL}, utils.LinkBuilder{})
+	second := util.FetchListArticles(context.Background(), parse, []string{server.URL}, utils.LinkBuilder{})
+
+	//assertions
+	assert.Equal(t, []models.Article{{Title: "Satu"}}, first)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, parses)
+}
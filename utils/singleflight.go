@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// flight is one in-progress load shared by every caller asking for its key.
+type flight struct {
+	key   string
+	done  chan struct{}
+	value interface{}
+	err   error
+
+	cancel context.CancelFunc
+	// waiters and detached are guarded by the group's mu.
+	waiters  int
+	detached bool
+}
+
+// flightGroup coalesces concurrent loads of the same key into one.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// start runs fn in the background unless a load for key is already running,
+// and returns the flight to wait on either way. fn gets ctx's values but not
+// its cancellation: a detached flight always runs to completion, any other is
+// cancelled once every caller waiting on it has given up.
+func (g *flightGroup) start(ctx context.Context, key string, detached bool, fn func(ctx context.Context) (interface{}, error)) *flight {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.flights[key]; ok {
+		f.waiters++
+		f.detached = f.detached || detached
+		return f
+	}
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+
+	loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	f := &flight{key: key, done: make(chan struct{}), cancel: cancel, waiters: 1, detached: detached}
+	g.flights[key] = f
+	go func() {
+		f.value, f.err = fn(loadCtx)
+		g.mu.Lock()
+		g.forget(f)
+		g.mu.Unlock()
+		cancel()
+		close(f.done)
+	}()
+	return f
+}
+
+// wait returns f's result, or ctx's error if ctx is done first. The last
+// caller to give up on a flight that isn't detached cancels it.
+func (g *flightGroup) wait(ctx context.Context, f *flight) (interface{}, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 && !f.detached {
+			// later callers start over rather than join a cancelled load
+			g.forget(f)
+			f.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes f from the group unless a newer flight replaced it. Callers
+// hold g.mu.
+func (g *flightGroup) forget(f *flight) {
+	if g.flights[f.key] == f {
+		delete(g.flights, f.key)
+	}
+}